@@ -3,9 +3,7 @@ package handlers
 import (
 	"bookstore-api/api/service"
 	"bookstore-api/internal/lib/errs"
-	"bookstore-api/internal/lib/sl"
 	"bookstore-api/internal/models"
-	"errors"
 	"log/slog"
 	"net/http"
 
@@ -14,10 +12,12 @@ import (
 
 type BookHandler struct {
 	Service service.BookService
+	// RequireIfMatch rejects PATCH and DELETE without If-Match
+	RequireIfMatch bool
 }
 
-func NewBookHandler(service service.BookService) *BookHandler {
-	return &BookHandler{Service: service}
+func NewBookHandler(service service.BookService, requireIfMatch bool) *BookHandler {
+	return &BookHandler{Service: service, RequireIfMatch: requireIfMatch}
 }
 
 // @Summary Get books of all users
@@ -28,34 +28,19 @@ func NewBookHandler(service service.BookService) *BookHandler {
 // @Accept json
 // @Produce json
 // @Success 200 {object} models.UsersBooksResponse "List of books of all users"
-// @Failure 401 {object} models.ErrorResponse "User unauthorized"
-// @Failure 404 {object} models.ErrorResponse "Records not found"
-// @Failure 500 {object} models.ErrorResponse "Database or Server error"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 404 {object} models.Problem "Records not found"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
 // @Router /admin/books [get]
 func (b *BookHandler) GetAllBooks(c *gin.Context) {
-	books, err := b.Service.GetAllBooks()
+	books, err := b.Service.GetAllBooks(c.Request.Context())
 	if err != nil {
-		slog.Error("handlers.GetAllBooks", sl.Error(err))
-
-		switch {
-		case errors.Is(err, errs.ErrNotFound):
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Error: "Could not found records",
-			})
-		case errors.Is(err, errs.ErrDBOperation):
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Database operation failed",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Internal server error",
-			})
-		}
-
+		c.Error(err)
 		return
 	}
 
-	slog.Debug("Books quantity", "number", len(books))
+	slog.DebugContext(c.Request.Context(), "Books quantity", "number", len(books))
 
 	c.JSON(http.StatusOK, models.UsersBooksResponse{
 		Data: books,
@@ -72,18 +57,19 @@ func (b *BookHandler) GetAllBooks(c *gin.Context) {
 // @Param author query string false "Filter by author name" example("Пушкин")
 // @Param title query string false "Filter by title" example("Я вас любил")
 // @Param limit query int false "Limit number of records" minimum(1) example(10)
+// @Param If-None-Match header string false "ETag of a list the client has, answered with 304 while it is current"
 // @Success 200 {object} models.GetBooks "List of books of user"
-// @Failure 400 {object} models.ErrorResponse "Invalid query body"
-// @Failure 401 {object} models.ErrorResponse "User unauthorized"
-// @Failure 404 {object} models.ErrorResponse "Records not found"
-// @Failure 500 {object} models.ErrorResponse "Database or Server error"
+// @Success 304 "List unchanged since the ETag in If-None-Match"
+// @Failure 400 {object} models.Problem "Invalid query body"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 404 {object} models.Problem "Records not found"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
 // @Router /api/books [get]
 func (b *BookHandler) GetUserBooks(c *gin.Context) {
 	userID_iface, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
+		c.Error(errs.New(errs.CodeNotAuthorized, "authentication_required"))
 		return
 	}
 
@@ -91,44 +77,24 @@ func (b *BookHandler) GetUserBooks(c *gin.Context) {
 	title := c.Query("title")
 	limitStr := c.Query("limit")
 
-	slog.Info("GetUserBooks request",
+	slog.InfoContext(c.Request.Context(), "GetUserBooks request",
 		"author", author,
 		"title", title,
 		"limit", limitStr,
 	)
 
-	books, userID, err := b.Service.GetUserBooks(userID_iface, author, title, limitStr)
+	books, userID, err := b.Service.GetUserBooks(c.Request.Context(), userID_iface, author, title, limitStr)
 	if err != nil {
-		slog.Error("handlers.GetUserBooks", sl.Error(err))
-
-		switch {
-		case errors.Is(err, errs.ErrInvalidParam):
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Error: "Invalid limit value",
-			})
-		case errors.Is(err, errs.ErrNotFound):
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Error: "Could not found records",
-			})
-		case errors.Is(err, errs.ErrDBOperation):
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Database operation failed",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Internal server error",
-			})
-		}
-
+		c.Error(err)
 		return
 	}
 
-	slog.Debug("GetUserBooks response",
+	slog.DebugContext(c.Request.Context(), "GetUserBooks response",
 		"books quantity", len(books),
 		"userID", userID,
 	)
 
-	c.JSON(http.StatusOK, models.GetBooks{
+	jsonWithETag(c, http.StatusOK, "", models.GetBooks{
 		Data: books,
 		Meta: models.MetaBook{
 			Total:  len(books),
@@ -137,6 +103,41 @@ func (b *BookHandler) GetUserBooks(c *gin.Context) {
 	})
 }
 
+// @Summary Get book
+// @Description Get a book of user with its version in ETag, send it in If-Match to update or delete the book
+// @Tags Books
+// @ID get-user-book
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID of the book" minimum(1) example(13)
+// @Param If-None-Match header string false "ETag of the book the client has, answered with 304 while it is current"
+// @Success 200 {object} models.Book "Book, ETag header holds its version"
+// @Success 304 "Book unchanged since the ETag in If-None-Match"
+// @Failure 400 {object} models.Problem "Invalid book ID"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 404 {object} models.Problem "Record not found"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
+// @Router /api/books/{id} [get]
+func (b *BookHandler) GetBook(c *gin.Context) {
+	userID_iface, exists := c.Get("userID")
+	if !exists {
+		c.Error(errs.New(errs.CodeNotAuthorized, "authentication_required"))
+		return
+	}
+
+	bookIDStr := c.Param("id")
+
+	book, err := b.Service.GetBook(c.Request.Context(), userID_iface, bookIDStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	jsonWithETag(c, http.StatusOK, bookETag(book), book)
+}
+
 // @Summary Create book
 // @Description Create book with users parameters
 // @Tags Books
@@ -145,45 +146,47 @@ func (b *BookHandler) GetUserBooks(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body models.BookRequest true "Data for create book"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param X-Change-Reason header string false "Why the books are changed, saved in their history" maxlength(255)
+// @Param Prefer header string false "respond-async to get a job to poll instead of waiting for the result"
 // @Success 201 {object} models.SuccessResponse "Message about successfully creating"
-// @Failure 400 {object} models.ErrorResponse "Invalid request body"
-// @Failure 401 {object} models.ErrorResponse "User unauthorized"
-// @Failure 500 {object} models.ErrorResponse "Database or Server error"
+// @Success 202 {object} models.Job "Request accepted, poll GET /api/jobs/{id}"
+// @Failure 400 {object} models.Problem "Invalid request body"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 409 {object} models.Problem "Request with the same Idempotency-Key in progress"
+// @Failure 422 {object} models.Problem "Idempotency-Key reused with another body"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
 // @Router /api/books [post]
 func (b *BookHandler) PostBook(c *gin.Context) {
 	userID_iface, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
+		c.Error(errs.New(errs.CodeNotAuthorized, "authentication_required"))
 		return
 	}
 
 	var input models.BookRequest
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		slog.Error("handlers.PostBook", sl.Error(err))
-
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Invalid body request",
-		})
+		c.Error(bindError(c, err))
 		return
 	}
 
-	slog.Info("book to add", "book", input)
+	slog.InfoContext(c.Request.Context(), "book to add", "book", input)
 
-	err := b.Service.PostBook(userID_iface, input)
+	job, err := b.Service.PostBook(c.Request.Context(), userID_iface, input)
 	if err != nil {
-		slog.Error("handlers.PostBook", sl.Error(err))
+		c.Error(err)
+		return
+	}
 
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: "Database operation failed",
-		})
+	if job != nil {
+		acceptJob(c, job)
 		return
 	}
 
 	c.JSON(http.StatusCreated, models.SuccessResponse{
-		Message: "Book was successfully created",
+		Message: message(c, "book_created"),
 	})
 }
 
@@ -196,62 +199,67 @@ func (b *BookHandler) PostBook(c *gin.Context) {
 // @Produce json
 // @Param id path int true "ID of the book to change" minimum(1) example(13)
 // @Param request body models.BookRequest true "New data for change existing data"
-// @Success 200 {object} models.SuccessResponse "Message about successfully updating"
-// @Failure 400 {object} models.ErrorResponse "Invalid request body"
-// @Failure 401 {object} models.ErrorResponse "User unauthorized"
-// @Failure 404 {object} models.ErrorResponse "Record not found"
-// @Failure 500 {object} models.ErrorResponse "Database or Server error"
+// @Param If-Match header string false "ETag of the book from GET /api/books/{id}, the update fails with 412 if the book changed since"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param X-Change-Reason header string false "Why the books are changed, saved in their history" maxlength(255)
+// @Param Prefer header string false "respond-async to get a job to poll instead of waiting for the result"
+// @Success 200 {object} models.SuccessResponse "Message about successfully updating, ETag header holds the new version"
+// @Success 202 {object} models.Job "Request accepted, poll GET /api/jobs/{id}"
+// @Failure 400 {object} models.Problem "Invalid request body or If-Match"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 404 {object} models.Problem "Record not found"
+// @Failure 409 {object} models.Problem "Request with the same Idempotency-Key in progress"
+// @Failure 412 {object} models.Problem "Book changed since If-Match, current holds the stored book"
+// @Failure 422 {object} models.Problem "Idempotency-Key reused with another body"
+// @Failure 428 {object} models.Problem "If-Match is required"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
 // @Router /api/books/{id} [patch]
 func (b *BookHandler) UpdateBook(c *gin.Context) {
 	userID_iface, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
+		c.Error(errs.New(errs.CodeNotAuthorized, "authentication_required"))
 		return
 	}
 
 	bookIDStr := c.Param("id")
 
-	slog.Info("book id for update", "id", bookIDStr)
+	slog.InfoContext(c.Request.Context(), "book id for update", "id", bookIDStr)
 
 	var input models.BookRequest
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		slog.Error("handlers.UpdateBook", sl.Error(err))
-
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Invalid body request",
-		})
+		c.Error(bindError(c, err))
 		return
 	}
 
-	slog.Info("new book", "book", input)
+	slog.InfoContext(c.Request.Context(), "new book", "book", input)
 
-	err := b.Service.UpdateBook(userID_iface, bookIDStr, input)
+	version, err := ifMatchVersion(c, b.RequireIfMatch)
 	if err != nil {
-		slog.Error("handlers.UpdateBook", sl.Error(err))
-
-		switch {
-		case errors.Is(err, errs.ErrNotFound):
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Error: "Could not found record",
-			})
-		case errors.Is(err, errs.ErrDBOperation):
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Database operation failed",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Internal server error",
-			})
-		}
+		c.Error(err)
+		return
+	}
 
+	book, job, err := b.Service.UpdateBook(c.Request.Context(), userID_iface, bookIDStr, input, version)
+	if err != nil {
+		conflictETag(c, err)
+		c.Error(err)
 		return
 	}
 
+	if job != nil {
+		acceptJob(c, job)
+		return
+	}
+
+	// a replayed request does not get the book back
+	if book.Version != 0 {
+		c.Header("ETag", bookETag(book))
+	}
+
 	c.JSON(http.StatusOK, models.SuccessResponse{
-		Message: "Alterations have been done",
+		Message: message(c, "book_updated"),
 	})
 }
 
@@ -263,52 +271,230 @@ func (b *BookHandler) UpdateBook(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "ID of the book to delete" minimum(1) example(3)
+// @Param If-Match header string false "ETag of the book from GET /api/books/{id}, the delete fails with 412 if the book changed since"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param X-Change-Reason header string false "Why the books are changed, saved in their history" maxlength(255)
+// @Param Prefer header string false "respond-async to get a job to poll instead of waiting for the result"
 // @Success 200 {object} models.SuccessResponse "Message about successfully deleting"
-// @Failure 400 {object} models.ErrorResponse "Invalid request body"
-// @Failure 401 {object} models.ErrorResponse "User unauthorized"
-// @Failure 404 {object} models.ErrorResponse "Record not found"
-// @Failure 500 {object} models.ErrorResponse "Database or Server error"
+// @Success 202 {object} models.Job "Request accepted, poll GET /api/jobs/{id}"
+// @Failure 400 {object} models.Problem "Invalid request body or If-Match"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 404 {object} models.Problem "Record not found"
+// @Failure 409 {object} models.Problem "Request with the same Idempotency-Key in progress"
+// @Failure 412 {object} models.Problem "Book changed since If-Match, current holds the stored book"
+// @Failure 422 {object} models.Problem "Idempotency-Key reused with another body"
+// @Failure 428 {object} models.Problem "If-Match is required"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
 // @Router /api/books/{id} [delete]
 func (b *BookHandler) DeleteBook(c *gin.Context) {
 	userID_iface, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: "Authentication required",
-		})
+		c.Error(errs.New(errs.CodeNotAuthorized, "authentication_required"))
 		return
 	}
 
 	bookIDStr := c.Param("id")
 
-	slog.Info("book id to delete", "id", bookIDStr)
+	slog.InfoContext(c.Request.Context(), "book id to delete", "id", bookIDStr)
 
-	err := b.Service.DeleteBook(userID_iface, bookIDStr)
+	version, err := ifMatchVersion(c, b.RequireIfMatch)
 	if err != nil {
-		slog.Error("handlers.DeleteBook", sl.Error(err))
-
-		switch {
-		case errors.Is(err, errs.ErrInvalidID):
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Error: "Invalid ID",
-			})
-		case errors.Is(err, errs.ErrNotFound):
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Error: "Could not found record",
-			})
-		case errors.Is(err, errs.ErrDBOperation):
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Database operation failed",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Internal server error",
-			})
-		}
+		c.Error(err)
+		return
+	}
 
+	job, err := b.Service.DeleteBook(c.Request.Context(), userID_iface, bookIDStr, version)
+	if err != nil {
+		conflictETag(c, err)
+		c.Error(err)
+		return
+	}
+
+	if job != nil {
+		acceptJob(c, job)
 		return
 	}
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
-		Message: "Book was successfully deleted",
+		Message: message(c, "book_deleted"),
 	})
 }
+
+// @Summary Get book history
+// @Description Get the revisions of a book oldest first: the book after every change, or before it was deleted, who made the change, why, and which fields it changed. The history is kept after the book is deleted
+// @Tags Books
+// @ID get-user-book-history
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID of the book" minimum(1) example(13)
+// @Success 200 {object} models.BookHistoryResponse "Revisions of the book"
+// @Failure 400 {object} models.Problem "Invalid book ID"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 404 {object} models.Problem "Book has no history"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
+// @Router /api/books/{id}/history [get]
+func (b *BookHandler) GetBookHistory(c *gin.Context) {
+	userID_iface, exists := c.Get("userID")
+	if !exists {
+		c.Error(errs.New(errs.CodeNotAuthorized, "authentication_required"))
+		return
+	}
+
+	bookIDStr := c.Param("id")
+
+	history, err := b.Service.GetBookHistory(c.Request.Context(), userID_iface, bookIDStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// @Summary Revert book
+// @Description Set title, author and price of the book back to a revision from its history. The revert is an ordinary update: it makes a new revision and checks If-Match. A deleted book is recreated with its old ID from the revision, If-Match then holds the version it was deleted at
+// @Tags Books
+// @ID revert-user-book
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID of the book to revert" minimum(1) example(13)
+// @Param revision query int true "Revision to restore, see GET /api/books/{id}/history" minimum(1) example(7)
+// @Param If-Match header string false "ETag of the book from GET /api/books/{id}, the revert fails with 412 if the book changed since"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param X-Change-Reason header string false "Why the book is reverted, by default the revision it is reverted to" maxlength(255)
+// @Param Prefer header string false "respond-async to get a job to poll instead of waiting for the result"
+// @Success 200 {object} models.SuccessResponse "Message about successfully reverting, ETag header holds the new version"
+// @Success 202 {object} models.Job "Request accepted, poll GET /api/jobs/{id}"
+// @Failure 400 {object} models.Problem "Invalid book ID, revision or If-Match"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 404 {object} models.Problem "Book or revision not found"
+// @Failure 409 {object} models.Problem "Request with the same Idempotency-Key in progress"
+// @Failure 412 {object} models.Problem "Book changed since If-Match, current holds the stored book"
+// @Failure 422 {object} models.Problem "Idempotency-Key reused with another body"
+// @Failure 428 {object} models.Problem "If-Match is required"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
+// @Router /api/books/{id}/revert [post]
+func (b *BookHandler) RevertBook(c *gin.Context) {
+	userID_iface, exists := c.Get("userID")
+	if !exists {
+		c.Error(errs.New(errs.CodeNotAuthorized, "authentication_required"))
+		return
+	}
+
+	bookIDStr := c.Param("id")
+	revisionStr := c.Query("revision")
+
+	slog.InfoContext(c.Request.Context(), "book to revert", "id", bookIDStr, "revision", revisionStr)
+
+	version, err := ifMatchVersion(c, b.RequireIfMatch)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	book, job, err := b.Service.RevertBook(c.Request.Context(), userID_iface, bookIDStr, revisionStr, version)
+	if err != nil {
+		conflictETag(c, err)
+		c.Error(err)
+		return
+	}
+
+	if job != nil {
+		acceptJob(c, job)
+		return
+	}
+
+	if book.Version != 0 {
+		c.Header("ETag", bookETag(book))
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: message(c, "book_reverted"),
+	})
+}
+
+// @Summary Batch book operations
+// @Description Create, update and delete books in one transaction. In atomic mode (default) one failed operation rolls back the whole batch, in per_item mode the successful operations are kept
+// @Tags Books
+// @ID batch-user-books
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.BatchRequest true "Operations to apply, at most 1000"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Param X-Change-Reason header string false "Why the books are changed, saved in their history" maxlength(255)
+// @Param Prefer header string false "respond-async to get a job to poll instead of waiting for the result"
+// @Success 200 {object} models.BatchResponse "Every operation succeeded"
+// @Success 207 {object} models.BatchResponse "Some operations failed, see the status of each one"
+// @Success 202 {object} models.Job "Request accepted, poll GET /api/jobs/{id}"
+// @Failure 400 {object} models.Problem "Invalid request body"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 409 {object} models.Problem "Request with the same Idempotency-Key in progress"
+// @Failure 422 {object} models.Problem "Idempotency-Key reused with another body"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
+// @Router /api/books/batch [post]
+func (b *BookHandler) BatchBooks(c *gin.Context) {
+	userID_iface, exists := c.Get("userID")
+	if !exists {
+		c.Error(errs.New(errs.CodeNotAuthorized, "authentication_required"))
+		return
+	}
+
+	var input models.BatchRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(bindError(c, err))
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "batch of books", "mode", input.Mode, "operations", len(input.Operations))
+
+	res, job, err := b.Service.BatchBooks(c.Request.Context(), userID_iface, input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if job != nil {
+		acceptJob(c, job)
+		return
+	}
+
+	status := http.StatusOK
+	response := models.BatchResponse{
+		Committed: res.Committed,
+		Results:   make([]models.BatchResult, len(res.Results)),
+	}
+	for i, r := range res.Results {
+		response.Results[i] = models.BatchResult{
+			Index:  r.Index,
+			Op:     r.Op,
+			ID:     r.ID,
+			Status: batchStatus(r),
+		}
+		if r.Error != nil {
+			response.Results[i].Error = string(r.Error.Code)
+			status = http.StatusMultiStatus
+		}
+	}
+
+	c.JSON(status, response)
+}
+
+// batchStatus is the HTTP status the operation would get as a single request
+func batchStatus(r models.BatchBookResult) int {
+	if r.Error == nil {
+		if r.Op == models.BatchOpCreate {
+			return http.StatusCreated
+		}
+		return http.StatusOK
+	}
+
+	return errs.Status(r.Error.Code)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/i18n"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// name rejected fields like clients send them
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// bindError reports a body that could not be decoded or validated, listing
+// the rejected fields with reasons in the language of the request
+func bindError(c *gin.Context, err error) error {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return errs.Wrap(errs.CodeInvalidBody, "", err)
+	}
+
+	locale := i18n.FromContext(c.Request.Context())
+
+	e := errs.Wrap(errs.CodeInvalidBody, "", err)
+	for _, fe := range invalid {
+		e.Fields = append(e.Fields, errs.FieldError{
+			Field:  fieldPath(fe),
+			Reason: reason(locale, fe),
+		})
+	}
+	return e
+}
+
+// fieldPath drops the name of the request struct, e.g. "operations[0].op"
+func fieldPath(fe validator.FieldError) string {
+	_, path, _ := strings.Cut(fe.Namespace(), ".")
+	return path
+}
+
+func reason(locale string, fe validator.FieldError) string {
+	tag := fe.Tag()
+	if tag == "min" && fe.Kind() == reflect.Slice {
+		tag = "min_items"
+	}
+	return i18n.Validation(locale, tag, fe.Param())
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonWithETag writes v with etag, or with a strong ETag of its encoding
+// when etag is empty. A request whose If-None-Match names that ETag gets 304
+// without a body
+func jsonWithETag(c *gin.Context, status int, etag string, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if etag == "" {
+		sum := sha256.Sum256(body)
+		etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	}
+
+	c.Header("ETag", etag)
+	// the answer depends on the token, shared caches must not keep it
+	c.Header("Cache-Control", "private, no-cache")
+	c.Writer.Header().Add("Vary", "Authorization")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(status, "application/json; charset=utf-8", body)
+}
+
+// etagMatches compares If-None-Match weakly, as RFC 9110 asks for GET
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bookETag is the version of the book, it changes with every update
+func bookETag(book models.Book) string {
+	return `"` + strconv.FormatUint(uint64(book.Version), 10) + `"`
+}
+
+// ifMatchVersion returns the version a PATCH or DELETE expects the book to
+// be at. Zero means any version: no If-Match, unless it is required, or "*"
+func ifMatchVersion(c *gin.Context, required bool) (uint, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	switch header {
+	case "":
+		if required {
+			return 0, errs.New(errs.CodePreconditionRequired, "if_match_required")
+		}
+		return 0, nil
+	case "*":
+		return 0, nil
+	}
+
+	// versions are strong validators, a weak tag or a list of them is not
+	// something this API hands out
+	unquoted, ok := strings.CutPrefix(header, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+	version, err := strconv.ParseUint(unquoted, 10, 0)
+	if !ok || err != nil || version == 0 {
+		return 0, errs.New(errs.CodeInvalidParam, "if_match_invalid")
+	}
+
+	return uint(version), nil
+}
+
+// conflictETag sets the ETag of the current book of a failed precondition,
+// so the client can retry with it
+func conflictETag(c *gin.Context, err error) {
+	var e *errs.Error
+	if !errors.As(err, &e) {
+		return
+	}
+	if book, ok := e.Current.(models.Book); ok {
+		c.Header("ETag", bookETag(book))
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/models"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testContext(header http.Header) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/books/1", nil)
+	c.Request.Header = header
+	return c, w
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		ifMatch  string
+		required bool
+		want     uint
+		wantCode errs.Code
+	}{
+		{name: "absent", want: 0},
+		{name: "absent but required", required: true, wantCode: errs.CodePreconditionRequired},
+		{name: "any", ifMatch: "*", required: true, want: 0},
+		{name: "version", ifMatch: `"3"`, want: 3},
+		{name: "surrounding spaces", ifMatch: ` "3" `, want: 3},
+		{name: "unquoted", ifMatch: "3", wantCode: errs.CodeInvalidParam},
+		{name: "weak", ifMatch: `W/"3"`, wantCode: errs.CodeInvalidParam},
+		{name: "list", ifMatch: `"3", "4"`, wantCode: errs.CodeInvalidParam},
+		{name: "zero", ifMatch: `"0"`, wantCode: errs.CodeInvalidParam},
+		{name: "not a number", ifMatch: `"abc"`, wantCode: errs.CodeInvalidParam},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.ifMatch != "" {
+				header.Set("If-Match", tt.ifMatch)
+			}
+			c, _ := testContext(header)
+
+			got, err := ifMatchVersion(c, tt.required)
+			if tt.wantCode != "" {
+				var e *errs.Error
+				if !errors.As(err, &e) || e.Code != tt.wantCode {
+					t.Fatalf("ifMatchVersion() error = %v, want code %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ifMatchVersion() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ifMatchVersion() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookETagRoundTrip(t *testing.T) {
+	etag := bookETag(models.Book{Version: 42})
+	if etag != `"42"` {
+		t.Fatalf("bookETag() = %s, want \"42\"", etag)
+	}
+
+	c, _ := testContext(http.Header{"If-Match": {etag}})
+	if got, err := ifMatchVersion(c, true); err != nil || got != 42 {
+		t.Errorf("ifMatchVersion(bookETag) = %d, %v, want 42", got, err)
+	}
+}
+
+func TestJSONWithETag(t *testing.T) {
+	tests := []struct {
+		name        string
+		etag        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{name: "no condition", etag: `"7"`, wantStatus: http.StatusOK},
+		{name: "match", etag: `"7"`, ifNoneMatch: `"7"`, wantStatus: http.StatusNotModified},
+		{name: "weak match", etag: `"7"`, ifNoneMatch: `W/"7"`, wantStatus: http.StatusNotModified},
+		{name: "match in a list", etag: `"7"`, ifNoneMatch: `"6", "7"`, wantStatus: http.StatusNotModified},
+		{name: "any", etag: `"7"`, ifNoneMatch: "*", wantStatus: http.StatusNotModified},
+		{name: "other version", etag: `"7"`, ifNoneMatch: `"6"`, wantStatus: http.StatusOK},
+		{name: "hash of the body", ifNoneMatch: `"6"`, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.ifNoneMatch != "" {
+				header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			c, w := testContext(header)
+
+			jsonWithETag(c, http.StatusOK, tt.etag, gin.H{"id": 1})
+			c.Writer.WriteHeaderNow()
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if w.Header().Get("ETag") == "" {
+				t.Error("ETag is not set")
+			}
+			if tt.etag != "" && w.Header().Get("ETag") != tt.etag {
+				t.Errorf("ETag = %s, want %s", w.Header().Get("ETag"), tt.etag)
+			}
+			if tt.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 has a body: %s", w.Body)
+			}
+		})
+	}
+}
+
+// the hash of the body is stable, so a client can revalidate with it
+func TestJSONWithETagHash(t *testing.T) {
+	c, w := testContext(http.Header{})
+	jsonWithETag(c, http.StatusOK, "", gin.H{"id": 1})
+	etag := w.Header().Get("ETag")
+
+	c, w = testContext(http.Header{"If-None-Match": {etag}})
+	jsonWithETag(c, http.StatusOK, "", gin.H{"id": 1})
+	c.Writer.WriteHeaderNow()
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotModified)
+	}
+}
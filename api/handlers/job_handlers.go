@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/middleware"
+	"bookstore-api/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get job
+// @Description Status of a write request sent with "Prefer: respond-async". Jobs are kept for 24 hours
+// @Tags Books
+// @ID get-job
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID returned with 202 Accepted" example(5f0c7a4e-8a8e-4d4b-9d43-1d2b2f1c9e77)
+// @Success 200 {object} models.Job "Job status, result or error code"
+// @Failure 400 {object} models.Problem "Invalid job ID"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 404 {object} models.Problem "Job not found"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
+// @Router /api/jobs/{id} [get]
+func (b *BookHandler) GetJob(c *gin.Context) {
+	userID_iface, exists := c.Get("userID")
+	if !exists {
+		c.Error(errs.New(errs.CodeNotAuthorized, "authentication_required"))
+		return
+	}
+
+	job, err := b.Service.GetJob(c.Request.Context(), userID_iface, c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// acceptJob answers a respond-async request once it is in Kafka
+func acceptJob(c *gin.Context, job *models.Job) {
+	c.Header(middleware.PreferenceAppliedHeader, middleware.PreferRespondAsync)
+	c.Header("Location", "/api/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
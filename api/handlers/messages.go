@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"bookstore-api/internal/lib/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// message returns the success message key in the language of the request
+func message(c *gin.Context, key string, args ...any) string {
+	return i18n.Message(i18n.FromContext(c.Request.Context()), key, args...)
+}
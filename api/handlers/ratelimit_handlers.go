@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bookstore-api/api/service"
+	"bookstore-api/internal/models"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RateLimitHandler struct {
+	Service service.RateLimitService
+}
+
+func NewRateLimitHandler(s service.RateLimitService) *RateLimitHandler {
+	return &RateLimitHandler{Service: s}
+}
+
+// @Summary Get rate limit overrides
+// @Description Get the rate limits set for single users
+// @Tags Admin
+// @ID get-rate-limits
+// @Security BasicAuth
+// @Produce json
+// @Success 200 {object} models.RateLimitsResponse "Rate limits of users"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
+// @Router /admin/rate-limits [get]
+func (h *RateLimitHandler) GetOverrides(c *gin.Context) {
+	overrides, err := h.Service.GetOverrides()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RateLimitsResponse{
+		Overrides: overrides,
+	})
+}
+
+// @Summary Set rate limit of a user
+// @Description Replace the rate limit of the /api routes for the user
+// @Tags Admin
+// @ID set-rate-limit
+// @Security BasicAuth
+// @Accept json
+// @Produce json
+// @Param username path string true "Username"
+// @Param request body models.RateLimitRequest true "Token bucket of the user"
+// @Success 200 {object} models.SuccessResponse "Rate limit saved"
+// @Failure 400 {object} models.Problem "Invalid body request or period"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 404 {object} models.Problem "User not found"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
+// @Router /admin/users/{username}/rate-limit [put]
+func (h *RateLimitHandler) SetOverride(c *gin.Context) {
+	username := c.Param("username")
+
+	var input models.RateLimitRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(bindError(c, err))
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "rate limit override", "username", username, "limit", input)
+
+	if err := h.Service.SetOverride(username, input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: message(c, "rate_limit_saved"),
+	})
+}
+
+// @Summary Remove rate limit of a user
+// @Description Return the user to the limit of the /api routes
+// @Tags Admin
+// @ID delete-rate-limit
+// @Security BasicAuth
+// @Produce json
+// @Param username path string true "Username"
+// @Success 200 {object} models.SuccessResponse "Rate limit removed"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 404 {object} models.Problem "User or rate limit not found"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
+// @Router /admin/users/{username}/rate-limit [delete]
+func (h *RateLimitHandler) DeleteOverride(c *gin.Context) {
+	username := c.Param("username")
+
+	slog.InfoContext(c.Request.Context(), "rate limit override to delete", "username", username)
+
+	if err := h.Service.DeleteOverride(username); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: message(c, "rate_limit_removed"),
+	})
+}
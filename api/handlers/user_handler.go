@@ -3,10 +3,7 @@ package handlers
 import (
 	"bookstore-api/api/service"
 	"bookstore-api/internal/lib/errs"
-	"bookstore-api/internal/lib/sl"
 	"bookstore-api/internal/models"
-	"errors"
-	"fmt"
 	"log/slog"
 	"net/http"
 
@@ -29,48 +26,29 @@ func NewUserHandler(s service.UserService) *UserHandler {
 // @Produce json
 // @Param request body models.Request true "Credentials for create user account"
 // @Success 200 {object} models.SuccessResponse "User created successfully"
-// @Failure 400 {object} models.ErrorResponse "Invalid body request"
-// @Failure 401 {object} models.ErrorResponse "User unauthorized"
-// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Failure 400 {object} models.Problem "Invalid body request"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Internal Server Error"
 // @Router /auth/register [post]
 func (u *UserHandler) Register(c *gin.Context) {
 	var creds models.Request
 
 	if err := c.ShouldBindJSON(&creds); err != nil {
-		slog.Error("handlers.Register", sl.Error(err))
-
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Invalid body request",
-		})
+		c.Error(bindError(c, err))
 		return
 	}
 
-	slog.Info("credentials", "creds", creds)
+	slog.InfoContext(c.Request.Context(), "credentials", "creds", creds)
 
 	err := u.Service.CreateUser(creds)
 	if err != nil {
-		slog.Error("handlers.Register", sl.Error(err))
-
-		switch {
-		case errors.Is(err, errs.ErrInternal):
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Generate password failed",
-			})
-		case errors.Is(err, errs.ErrDBOperation):
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Database operation failed",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Internal server error",
-			})
-		}
-
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, models.SuccessResponse{
-		Message: "User created",
+		Message: message(c, "user_created"),
 	})
 }
 
@@ -82,59 +60,30 @@ func (u *UserHandler) Register(c *gin.Context) {
 // @Produce json
 // @Param request body models.Request true "Credentials for login in created accound"
 // @Success 200 {object} models.SuccessResponse "Give Token after successfully authorization"
-// @Failure 400 {object} models.ErrorResponse "Invalid body request"
-// @Failure 401 {object} models.ErrorResponse "User do not registred"
-// @Failure 403 {object} models.ErrorResponse "Incorrect password"
-// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Failure 400 {object} models.Problem "Invalid body request"
+// @Failure 401 {object} models.Problem "User do not registred"
+// @Failure 403 {object} models.Problem "Incorrect password"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Internal Server Error"
 // @Router /auth/login [post]
 func (u *UserHandler) Login(c *gin.Context) {
 	var creds models.Request
 
 	if err := c.ShouldBindJSON(&creds); err != nil {
-		slog.Error("handlers.Login 94", sl.Error(err))
-
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Invalid body request",
-		})
+		c.Error(bindError(c, err))
 		return
 	}
 
-	slog.Info("credentials", "creds", creds)
+	slog.InfoContext(c.Request.Context(), "credentials", "creds", creds)
 
 	token, err := u.Service.GetUserToken(creds)
 	if err != nil {
-		slog.Error("handlers.Login 106", sl.Error(err))
-
-		switch {
-		case errors.Is(err, errs.ErrNotRegistred):
-			c.JSON(http.StatusForbidden, models.ErrorResponse{
-				Error: "Incorrect password",
-			})
-		case errors.Is(err, errs.ErrNotAuthorized):
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error: "You are not authorized",
-			})
-		case errors.Is(err, errs.ErrDBOperation):
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Database operation failed",
-			})
-		case errors.Is(err, errs.ErrInternal):
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Generate JWT token failed",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Internal server error",
-			})
-		}
-
+		c.Error(err)
 		return
 	}
 
-	slog.Debug("users jwt token", "token", token)
-
 	c.JSON(http.StatusOK, models.SuccessResponse{
-		Message: fmt.Sprintf("Your token: %s", token),
+		Message: message(c, "token_issued", token),
 	})
 }
 
@@ -146,35 +95,20 @@ func (u *UserHandler) Login(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} models.UsersResponse "List of all users"
-// @Failure 401 {object} models.ErrorResponse "User unauthorized"
-// @Failure 404 {object} models.ErrorResponse "Records not found"
-// @Failure 500 {object} models.ErrorResponse "Database or Server error"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 404 {object} models.Problem "Records not found"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
 // @Router /admin/users [get]
 func (u *UserHandler) GetAllUsers(c *gin.Context) {
 	users, err := u.Service.GetAllUsers()
 
 	if err != nil {
-		slog.Error("handlers.GetAllUsers 157", sl.Error(err))
-
-		switch {
-		case errors.Is(err, errs.ErrNotFound):
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Error: "Could not found records",
-			})
-		case errors.Is(err, errs.ErrDBOperation):
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Database operation failed",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Internal server error",
-			})
-		}
-
+		c.Error(err)
 		return
 	}
 
-	slog.Debug("users quantity", "number", len(users))
+	slog.DebugContext(c.Request.Context(), "users quantity", "number", len(users))
 
 	c.JSON(http.StatusOK, models.UsersResponse{
 		Users: users,
@@ -190,38 +124,62 @@ func (u *UserHandler) GetAllUsers(c *gin.Context) {
 // @Produce json
 // @Param username path string true "Username to delete"
 // @Success 200 {object} models.UsersResponse "Message about successfully deleting"
-// @Failure 401 {object} models.ErrorResponse "User unauthorized"
-// @Failure 404 {object} models.ErrorResponse "Record not found"
-// @Failure 500 {object} models.ErrorResponse "Database or Server error"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 404 {object} models.Problem "Record not found"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
 // @Router /admin/users/{username} [delete]
 func (u *UserHandler) DeleteByUsername(c *gin.Context) {
 	username := c.Param("username")
 
-	slog.Info("username to delete", "username", username)
+	slog.InfoContext(c.Request.Context(), "username to delete", "username", username)
 
 	err := u.Service.DeleteByUsername(username)
 	if err != nil {
-		slog.Error("handlers.DeleteByUsername 204", sl.Error(err))
-
-		switch {
-		case errors.Is(err, errs.ErrNotFound):
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Error: "Could not found record",
-			})
-		case errors.Is(err, errs.ErrDBOperation):
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Database operation failed",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Internal server error",
-			})
-		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: message(c, "user_deleted"),
+	})
+}
+
+// @Summary Set language
+// @Description Save the language of messages and errors for the user. It overrides Accept-Language, an empty locale removes the override
+// @Tags Authorization
+// @ID set-user-locale
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.LocaleRequest true "Locale: ru, en or empty"
+// @Success 200 {object} models.SuccessResponse "Locale saved"
+// @Failure 400 {object} models.Problem "Unsupported locale"
+// @Failure 401 {object} models.Problem "User unauthorized"
+// @Failure 429 {object} models.Problem "Too many requests"
+// @Failure 500 {object} models.Problem "Database or Server error"
+// @Router /api/me/locale [put]
+func (u *UserHandler) SetLocale(c *gin.Context) {
+	userID_iface, exists := c.Get("userID")
+	if !exists {
+		c.Error(errs.New(errs.CodeNotAuthorized, "authentication_required"))
+		return
+	}
+
+	var input models.LocaleRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(bindError(c, err))
+		return
+	}
 
+	if err := u.Service.SetLocale(userID_iface, input.Locale); err != nil {
+		c.Error(err)
 		return
 	}
 
+	// the new locale applies from the next request on
 	c.JSON(http.StatusOK, models.SuccessResponse{
-		Message: "User account was successfully deleted",
+		Message: message(c, "locale_saved"),
 	})
 }
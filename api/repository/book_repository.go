@@ -2,23 +2,34 @@ package repository
 
 import (
 	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/reqctx"
 	"bookstore-api/internal/models"
+	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BookRepository interface {
-	GetAllBooks() ([]models.Book, error)
+	GetAllBooks(ctx context.Context) ([]models.Book, error)
 	GetUserBooks(
+		ctx context.Context,
 		userID uint,
 		author string,
 		title string,
 		limit int,
 	) ([]models.Book, models.KafkaError)
-	PostBook(book models.Book) models.KafkaError
-	UpdateBook(userID uint, bookID uint, newBook models.Book) models.KafkaError
-	DeleteBook(userID uint, bookID uint) models.KafkaError
+	GetBook(ctx context.Context, userID uint, bookID uint) (models.Book, models.KafkaError)
+	GetBookHistory(ctx context.Context, userID uint, bookID uint) ([]models.BookRevision, models.KafkaError)
+	PostBook(ctx context.Context, book models.Book, idempotencyKey string) models.KafkaError
+	UpdateBook(ctx context.Context, userID uint, bookID uint, newBook models.Book, idempotencyKey string) (models.Book, models.KafkaError)
+	DeleteBook(ctx context.Context, userID uint, bookID uint, version uint, idempotencyKey string) (models.Book, models.KafkaError)
+	RestoreBook(ctx context.Context, book models.Book, idempotencyKey string) (models.Book, models.KafkaError)
+	RevertBook(ctx context.Context, req models.RevertBook, idempotencyKey string) (models.Book, models.KafkaError)
+	BatchBooks(ctx context.Context, batch models.BatchBook, idempotencyKey string) (models.BatchBookResponse, models.KafkaError)
 }
 
 type bookRepository struct {
@@ -29,9 +40,9 @@ func NewBookRepository(db *gorm.DB) BookRepository {
 	return &bookRepository{db: db}
 }
 
-func (r *bookRepository) GetAllBooks() ([]models.Book, error) {
+func (r *bookRepository) GetAllBooks(ctx context.Context) ([]models.Book, error) {
 	var books []models.Book
-	result := r.db.Preload("User").Find(&books)
+	result := r.db.WithContext(ctx).Preload("User").Find(&books)
 
 	if result.Error != nil {
 		return nil, fmt.Errorf("%w: %v", errs.ErrDBOperation, result.Error)
@@ -44,14 +55,15 @@ func (r *bookRepository) GetAllBooks() ([]models.Book, error) {
 }
 
 func (r *bookRepository) GetUserBooks(
+	ctx context.Context,
 	userID uint,
 	author, title string,
 	limit int,
 ) ([]models.Book, models.KafkaError) {
 	var books []models.Book
 
-	query := r.db.Model(&models.Book{}).
-		Select("id, title, author, price").
+	query := r.db.WithContext(ctx).Model(&models.Book{}).
+		Select("id, title, author, price, version, created_at, updated_at").
 		Where("user_id = ?", userID)
 
 	if author != "" {
@@ -69,92 +81,702 @@ func (r *bookRepository) GetUserBooks(
 	result := query.Find(&books)
 	if result.Error != nil {
 		return nil, models.KafkaError{
-			Error:   errs.ErrDBOperation.Error(),
+			Code:    errs.CodeDBOperation,
 			Message: result.Error.Error(),
 		}
 	}
 
 	if result.RowsAffected == 0 {
 		return nil, models.KafkaError{
-			Error: errs.ErrNotFound.Error(),
+			Code: errs.CodeNotFound,
 		}
 	}
 
 	return books, models.KafkaError{}
 }
 
-func (r *bookRepository) PostBook(book models.Book) models.KafkaError {
-	result := r.db.Create(&book)
+func (r *bookRepository) GetBook(ctx context.Context, userID, bookID uint) (models.Book, models.KafkaError) {
+	return findBook(r.db.WithContext(ctx), userID, bookID)
+}
+
+// GetBookHistory returns the revisions of the book oldest first, also when
+// the book was deleted
+func (r *bookRepository) GetBookHistory(
+	ctx context.Context,
+	userID, bookID uint,
+) ([]models.BookRevision, models.KafkaError) {
+	var revisions []models.BookRevision
+	result := r.db.WithContext(ctx).
+		Where(&models.BookRevision{BookID: bookID, UserID: userID}).
+		Order("id").
+		Find(&revisions)
 
 	if result.Error != nil {
-		return models.KafkaError{
-			Error:   errs.ErrDBOperation.Error(),
-			Message: fmt.Sprintf("could not create book %v", result.Error),
+		return nil, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not get book history " + result.Error.Error(),
 		}
 	}
+	if result.RowsAffected == 0 {
+		return nil, models.KafkaError{Code: errs.CodeNotFound}
+	}
 
-	return models.KafkaError{}
+	return revisions, models.KafkaError{}
 }
 
-func (r *bookRepository) UpdateBook(userID, bookID uint, book models.Book) models.KafkaError {
-	var Error, Message string
+func (r *bookRepository) PostBook(ctx context.Context, book models.Book, idempotencyKey string) models.KafkaError {
+	var errKafka models.KafkaError
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		claimed, err := claimRequest(tx, book.UserID, idempotencyKey, "PostBook", nil)
+		if err != nil {
+			errKafka = models.KafkaError{
+				Code:    errs.CodeDBOperation,
+				Message: fmt.Sprintf("could not create book %v", err),
+			}
+			return err
+		}
+		if !claimed {
+			return nil
+		}
+
+		errKafka = createBook(tx, &book)
+		return errKafka.Err()
+	})
+
+	if err != nil && errKafka.Code == "" {
+		errKafka = models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: fmt.Sprintf("could not create book %v", err),
+		}
+	}
+
+	return errKafka
+}
 
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		result := tx.Model(&models.Book{}).
-			Where(&models.Book{ID: bookID, UserID: userID}).
-			Select("Title", "Author", "Price").
-			Updates(book)
+// UpdateBook applies the change if the book is still at book.Version, or at
+// any version when it is 0. It returns the updated book, or the current one
+// when the version did not match
+func (r *bookRepository) UpdateBook(
+	ctx context.Context,
+	userID, bookID uint,
+	book models.Book,
+	idempotencyKey string,
+) (models.Book, models.KafkaError) {
+	var updated models.Book
+	var errKafka models.KafkaError
 
-		if result.Error != nil {
-			Error = errs.ErrDBOperation.Error()
-			Message = "could not update book " + result.Error.Error()
-			return fmt.Errorf("%w: cound not update book %v", errs.ErrDBOperation, result.Error)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		claimed, err := claimRequest(tx, userID, idempotencyKey, "UpdateBook", &updated)
+		if err != nil {
+			errKafka = models.KafkaError{
+				Code:    errs.CodeDBOperation,
+				Message: "could not update book " + err.Error(),
+			}
+			return err
 		}
-		if result.RowsAffected == 0 {
-			Error = errs.ErrNotFound.Error()
-			return errs.ErrNotFound
+		if !claimed {
+			return nil
 		}
 
+		updated, errKafka = updateBook(tx, userID, bookID, book)
+		if errKafka.Code != "" {
+			return errKafka.Err()
+		}
+
+		if err := storeResult(tx, userID, idempotencyKey, updated); err != nil {
+			errKafka = models.KafkaError{
+				Code:    errs.CodeDBOperation,
+				Message: "could not update book " + err.Error(),
+			}
+			return err
+		}
 		return nil
 	})
 
-	if err != nil {
-		return models.KafkaError{
-			Error:   Error,
-			Message: Message,
+	if err != nil && errKafka.Code == "" {
+		errKafka = models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not update book " + err.Error(),
 		}
 	}
 
-	return models.KafkaError{}
+	return updated, errKafka
 }
 
-func (r *bookRepository) DeleteBook(userID, bookID uint) models.KafkaError {
-	var Error, Message string
+// DeleteBook deletes the book if it is still at version, or at any version
+// when it is 0. When the version did not match the current book is returned
+func (r *bookRepository) DeleteBook(
+	ctx context.Context,
+	userID, bookID, version uint,
+	idempotencyKey string,
+) (models.Book, models.KafkaError) {
+	var current models.Book
+	var errKafka models.KafkaError
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		claimed, err := claimRequest(tx, userID, idempotencyKey, "DeleteBook", &current)
+		if err != nil {
+			errKafka = models.KafkaError{
+				Code:    errs.CodeDBOperation,
+				Message: "could not delete book " + err.Error(),
+			}
+			return err
+		}
+		if !claimed {
+			return nil
+		}
+
+		current, errKafka = deleteBook(tx, userID, bookID, version)
+		if errKafka.Code != "" {
+			return errKafka.Err()
+		}
+
+		if err := storeResult(tx, userID, idempotencyKey, current); err != nil {
+			errKafka = models.KafkaError{
+				Code:    errs.CodeDBOperation,
+				Message: "could not delete book " + err.Error(),
+			}
+			return err
+		}
+		return nil
+	})
+
+	if err != nil && errKafka.Code == "" {
+		errKafka = models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not delete book " + err.Error(),
+		}
+	}
+
+	return current, errKafka
+}
+
+// RestoreBook recreates a deleted book under its old ID, one version past
+// the one it was deleted at. book.Version is the deleted version the client
+// expects, 0 skips the check. A book that exists is returned as conflict
+func (r *bookRepository) RestoreBook(
+	ctx context.Context,
+	book models.Book,
+	idempotencyKey string,
+) (models.Book, models.KafkaError) {
+	var restored models.Book
+	var errKafka models.KafkaError
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		claimed, err := claimRequest(tx, book.UserID, idempotencyKey, "RestoreBook", &restored)
+		if err != nil {
+			errKafka = models.KafkaError{
+				Code:    errs.CodeDBOperation,
+				Message: "could not restore book " + err.Error(),
+			}
+			return err
+		}
+		if !claimed {
+			return nil
+		}
+
+		restored, errKafka = restoreBook(tx, book)
+		if errKafka.Code != "" {
+			return errKafka.Err()
+		}
+
+		if err := storeResult(tx, book.UserID, idempotencyKey, restored); err != nil {
+			errKafka = models.KafkaError{
+				Code:    errs.CodeDBOperation,
+				Message: "could not restore book " + err.Error(),
+			}
+			return err
+		}
+		return nil
+	})
+
+	if err != nil && errKafka.Code == "" {
+		errKafka = models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not restore book " + err.Error(),
+		}
+	}
+
+	return restored, errKafka
+}
 
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		result := tx.Unscoped().
-			Where(&models.Book{ID: bookID, UserID: userID}).
-			Delete(&models.Book{})
+// RevertBook sets the book back to a revision. The revision is read and
+// applied in one transaction, so a change in between can't slip through.
+// An existing book is updated at req.Version, a deleted one is restored
+func (r *bookRepository) RevertBook(
+	ctx context.Context,
+	req models.RevertBook,
+	idempotencyKey string,
+) (models.Book, models.KafkaError) {
+	var reverted models.Book
+	var errKafka models.KafkaError
 
-		if result.Error != nil {
-			Error = errs.ErrDBOperation.Error()
-			Message = "could not update book " + result.Error.Error()
-			return fmt.Errorf("%w: cound not update book %v", errs.ErrDBOperation, result.Error)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		claimed, err := claimRequest(tx, req.UserID, idempotencyKey, "RevertBook", &reverted)
+		if err != nil {
+			errKafka = models.KafkaError{
+				Code:    errs.CodeDBOperation,
+				Message: "could not revert book " + err.Error(),
+			}
+			return err
+		}
+		if !claimed {
+			return nil
 		}
-		if result.RowsAffected == 0 {
-			Error = errs.ErrNotFound.Error()
-			return errs.ErrNotFound
+
+		reverted, errKafka = revertBook(tx, req)
+		if errKafka.Code != "" {
+			return errKafka.Err()
 		}
 
+		if err := storeResult(tx, req.UserID, idempotencyKey, reverted); err != nil {
+			errKafka = models.KafkaError{
+				Code:    errs.CodeDBOperation,
+				Message: "could not revert book " + err.Error(),
+			}
+			return err
+		}
 		return nil
 	})
 
+	if err != nil && errKafka.Code == "" {
+		errKafka = models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not revert book " + err.Error(),
+		}
+	}
+
+	return reverted, errKafka
+}
+
+// BatchBooks applies the operations in one transaction. In per_item mode
+// every operation runs in its own savepoint so a failed one doesn't abort
+// the others. In atomic mode the first failure rolls everything back
+func (r *bookRepository) BatchBooks(
+	ctx context.Context,
+	batch models.BatchBook,
+	idempotencyKey string,
+) (models.BatchBookResponse, models.KafkaError) {
+	atomic := batch.Mode != models.BatchModePerItem
+	res := models.BatchBookResponse{
+		Results: make([]models.BatchBookResult, len(batch.Operations)),
+	}
+	failed := -1
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		claimed, err := claimRequest(tx, batch.UserID, idempotencyKey, "BatchBooks", &res)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return nil
+		}
+
+		for i, op := range batch.Operations {
+			res.Results[i] = models.BatchBookResult{Index: i, Op: op.Op, ID: op.Book.ID}
+
+			var errKafka models.KafkaError
+			apply := func(tx *gorm.DB) error {
+				errKafka = applyOperation(tx, batch.UserID, &op)
+				return errKafka.Err()
+			}
+
+			if atomic {
+				err = apply(tx)
+			} else {
+				err = tx.Transaction(apply)
+			}
+			if err != nil && errKafka.Code == "" {
+				errKafka = models.KafkaError{Code: errs.CodeDBOperation, Message: err.Error()}
+			}
+
+			if errKafka.Code == "" {
+				res.Results[i].ID = op.Book.ID
+				continue
+			}
+
+			res.Results[i].Error = &errKafka
+			if atomic {
+				failed = i
+				return errKafka.Err()
+			}
+		}
+
+		// stored as committed, it is only kept if the transaction commits
+		res.Committed = true
+		return storeResult(tx, batch.UserID, idempotencyKey, res)
+	})
+
+	if failed >= 0 {
+		for i, op := range batch.Operations {
+			if i == failed {
+				continue
+			}
+			res.Results[i] = models.BatchBookResult{
+				Index: i,
+				Op:    op.Op,
+				ID:    op.Book.ID,
+				Error: &models.KafkaError{Code: errs.CodeRolledBack},
+			}
+		}
+		return res, models.KafkaError{}
+	}
+
 	if err != nil {
+		return models.BatchBookResponse{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not apply batch " + err.Error(),
+		}
+	}
+
+	res.Committed = true
+	return res, models.KafkaError{}
+}
+
+func applyOperation(tx *gorm.DB, userID uint, op *models.BatchBookOperation) models.KafkaError {
+	op.Book.UserID = userID
+
+	switch op.Op {
+	case models.BatchOpCreate:
+		op.Book.ID = 0
+		return createBook(tx, &op.Book)
+	case models.BatchOpUpdate:
+		_, errKafka := updateBook(tx, userID, op.Book.ID, op.Book)
+		return errKafka
+	case models.BatchOpDelete:
+		_, errKafka := deleteBook(tx, userID, op.Book.ID, op.Book.Version)
+		return errKafka
+	}
+
+	return models.KafkaError{
+		Code:    errs.CodeInvalidParam,
+		Message: "unknown batch operation " + op.Op,
+	}
+}
+
+func createBook(tx *gorm.DB, book *models.Book) models.KafkaError {
+	// every book starts at the first version whatever the request carried
+	book.Version = 1
+	if err := tx.Create(book).Error; err != nil {
 		return models.KafkaError{
-			Error:   Error,
-			Message: Message,
+			Code:    errs.CodeDBOperation,
+			Message: fmt.Sprintf("could not create book %v", err),
+		}
+	}
+
+	if err := recordRevision(tx, models.RevisionCreate, *book); err != nil {
+		return models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not save book revision " + err.Error(),
+		}
+	}
+
+	if err := appendOutbox(tx, "book", book.ID, models.BookCreatedEvent, book); err != nil {
+		return models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not save book event " + err.Error(),
 		}
 	}
 
 	return models.KafkaError{}
 }
+
+// updateBook checks book.Version in the WHERE clause, so a concurrent change
+// between reading and writing the book cannot be overwritten
+func updateBook(tx *gorm.DB, userID, bookID uint, book models.Book) (models.Book, models.KafkaError) {
+	result := whereVersion(tx.Model(&models.Book{}), userID, bookID, book.Version).
+		Updates(map[string]interface{}{
+			"title":      book.Title,
+			"author":     book.Author,
+			"price":      book.Price,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		})
+
+	if result.Error != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not update book " + result.Error.Error(),
+		}
+	}
+	if result.RowsAffected == 0 {
+		return versionConflict(tx, userID, bookID)
+	}
+
+	var updated models.Book
+	if err := tx.First(&updated, bookID).Error; err != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not update book " + err.Error(),
+		}
+	}
+
+	if err := recordRevision(tx, models.RevisionUpdate, updated); err != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not save book revision " + err.Error(),
+		}
+	}
+
+	if err := appendOutbox(tx, "book", bookID, models.BookUpdatedEvent, updated); err != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not save book event " + err.Error(),
+		}
+	}
+
+	return updated, models.KafkaError{}
+}
+
+// deleteBook keeps the deleted row in the history, RETURNING hands it back
+// without another query
+func deleteBook(tx *gorm.DB, userID, bookID, version uint) (models.Book, models.KafkaError) {
+	var deleted []models.Book
+	result := whereVersion(tx.Unscoped().Clauses(clause.Returning{}), userID, bookID, version).
+		Delete(&deleted)
+
+	if result.Error != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not delete book " + result.Error.Error(),
+		}
+	}
+	if result.RowsAffected == 0 {
+		return versionConflict(tx, userID, bookID)
+	}
+
+	if err := recordRevision(tx, models.RevisionDelete, deleted[0]); err != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not save book revision " + err.Error(),
+		}
+	}
+
+	event := models.DeleteBook{ID: bookID, UserID: userID}
+	if err := appendOutbox(tx, "book", bookID, models.BookDeletedEvent, event); err != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not save book event " + err.Error(),
+		}
+	}
+
+	return models.Book{}, models.KafkaError{}
+}
+
+// recordRevision saves the book as a revision made by the actor of the
+// request, requests from older producers are attributed to the owner
+func recordRevision(tx *gorm.DB, operation string, book models.Book) error {
+	ctx := tx.Statement.Context
+	actor := reqctx.Actor(ctx)
+	if actor == "" {
+		actor = fmt.Sprintf("user:%d", book.UserID)
+	}
+
+	return tx.Create(&models.BookRevision{
+		BookID:    book.ID,
+		UserID:    book.UserID,
+		Version:   book.Version,
+		Operation: operation,
+		Title:     book.Title,
+		Author:    book.Author,
+		Price:     book.Price,
+		Actor:     actor,
+		Reason:    reqctx.ChangeReason(ctx),
+	}).Error
+}
+
+func restoreBook(tx *gorm.DB, book models.Book) (models.Book, models.KafkaError) {
+	current, errKafka := findBook(tx, book.UserID, book.ID)
+	switch {
+	case errKafka.Code == "":
+		return current, models.KafkaError{
+			Code:    errs.CodePreconditionFailed,
+			Message: fmt.Sprintf("book exists at version %d", current.Version),
+		}
+	case errKafka.Code != errs.CodeNotFound:
+		return models.Book{}, errKafka
+	}
+
+	// the history knows the version the book was deleted at and when it
+	// was created
+	var last struct {
+		Version   uint
+		CreatedAt time.Time
+	}
+	result := tx.Model(&models.BookRevision{}).
+		Select("COALESCE(MAX(version), 0) AS version, COALESCE(MIN(created_at), now()) AS created_at").
+		Where(&models.BookRevision{BookID: book.ID, UserID: book.UserID}).
+		Scan(&last)
+	if result.Error != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not restore book " + result.Error.Error(),
+		}
+	}
+	if last.Version == 0 {
+		return models.Book{}, models.KafkaError{Code: errs.CodeNotFound}
+	}
+	if book.Version != 0 && book.Version != last.Version {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodePreconditionFailed,
+			Message: fmt.Sprintf("book was deleted at version %d", last.Version),
+		}
+	}
+
+	restored := models.Book{
+		ID:        book.ID,
+		Title:     book.Title,
+		Author:    book.Author,
+		Price:     book.Price,
+		UserID:    book.UserID,
+		Version:   last.Version + 1,
+		CreatedAt: last.CreatedAt,
+	}
+	if err := tx.Create(&restored).Error; err != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not restore book " + err.Error(),
+		}
+	}
+
+	if err := recordRevision(tx, models.RevisionRestore, restored); err != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not save book revision " + err.Error(),
+		}
+	}
+
+	if err := appendOutbox(tx, "book", restored.ID, models.BookRestoredEvent, restored); err != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not save book event " + err.Error(),
+		}
+	}
+
+	return restored, models.KafkaError{}
+}
+
+func revertBook(tx *gorm.DB, req models.RevertBook) (models.Book, models.KafkaError) {
+	var target models.BookRevision
+	result := tx.Where(&models.BookRevision{ID: req.Revision, BookID: req.ID, UserID: req.UserID}).
+		Limit(1).
+		Find(&target)
+	if result.Error != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not get revision " + result.Error.Error(),
+		}
+	}
+	if result.RowsAffected == 0 {
+		return models.Book{}, models.KafkaError{Code: errs.CodeNotFound, Message: "no such revision"}
+	}
+
+	book := models.Book{
+		ID:      req.ID,
+		Title:   target.Title,
+		Author:  target.Author,
+		Price:   target.Price,
+		UserID:  req.UserID,
+		Version: req.Version,
+	}
+
+	_, errKafka := findBook(tx, req.UserID, req.ID)
+	switch errKafka.Code {
+	case "":
+		return updateBook(tx, req.UserID, req.ID, book)
+	case errs.CodeNotFound:
+		return restoreBook(tx, book)
+	}
+	return models.Book{}, errKafka
+}
+
+// whereVersion selects the book of the user, only at version unless it is 0
+func whereVersion(tx *gorm.DB, userID, bookID, version uint) *gorm.DB {
+	tx = tx.Where(&models.Book{ID: bookID, UserID: userID})
+	if version != 0 {
+		tx = tx.Where("version = ?", version)
+	}
+	return tx
+}
+
+// versionConflict explains why a write matched no row: the book is gone,
+// or it moved past the expected version and is returned as it is now
+func versionConflict(tx *gorm.DB, userID, bookID uint) (models.Book, models.KafkaError) {
+	current, errKafka := findBook(tx, userID, bookID)
+	if errKafka.Code != "" {
+		return models.Book{}, errKafka
+	}
+
+	return current, models.KafkaError{
+		Code:    errs.CodePreconditionFailed,
+		Message: fmt.Sprintf("book is at version %d", current.Version),
+	}
+}
+
+func findBook(tx *gorm.DB, userID, bookID uint) (models.Book, models.KafkaError) {
+	var book models.Book
+	result := tx.Where(&models.Book{ID: bookID, UserID: userID}).Limit(1).Find(&book)
+
+	if result.Error != nil {
+		return models.Book{}, models.KafkaError{
+			Code:    errs.CodeDBOperation,
+			Message: "could not get book " + result.Error.Error(),
+		}
+	}
+	if result.RowsAffected == 0 {
+		return models.Book{}, models.KafkaError{Code: errs.CodeNotFound}
+	}
+
+	return book, models.KafkaError{}
+}
+
+// claimRequest remembers a keyed request inside the transaction that applies
+// it. false means the request was already applied and must be skipped, its
+// stored result is then decoded into replay unless replay is nil
+func claimRequest(tx *gorm.DB, userID uint, key, method string, replay any) (bool, error) {
+	if key == "" {
+		return true, nil
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ProcessedRequest{
+		UserID: userID,
+		Key:    key,
+		Method: method,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 || replay == nil {
+		return result.RowsAffected == 1, nil
+	}
+
+	var processed models.ProcessedRequest
+	err := tx.Where(&models.ProcessedRequest{UserID: userID, Key: key}).Take(&processed).Error
+	if err != nil {
+		return false, err
+	}
+	// requests applied before results were kept have none to replay
+	if len(processed.Result) == 0 {
+		return false, nil
+	}
+
+	return false, json.Unmarshal(processed.Result, replay)
+}
+
+// storeResult keeps the result of a claimed request next to its key, so a
+// duplicate gets the same answer as the request that was applied
+func storeResult(tx *gorm.DB, userID uint, key string, res any) error {
+	if key == "" {
+		return nil
+	}
+
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.ProcessedRequest{}).
+		Where(&models.ProcessedRequest{UserID: userID, Key: key}).
+		Update("result", raw).Error
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository interface {
+	Reserve(rec models.IdempotencyKey, lease time.Duration) (models.IdempotencyKey, bool, error)
+	Complete(userID uint, key string, status int, headers http.Header, body []byte) error
+	Release(userID uint, key string) error
+	DeleteExpired(now time.Time, ttl time.Duration) error
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Reserve inserts a pending record for the key. When the key is already
+// taken by a live record, that record is returned with reserved == false.
+// A pending record older than lease is left by a request that never
+// finished, e.g. a crashed instance, and is taken over
+func (r *idempotencyRepository) Reserve(
+	rec models.IdempotencyKey,
+	lease time.Duration,
+) (models.IdempotencyKey, bool, error) {
+	var existing models.IdempotencyKey
+	reserved := false
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Where("user_id = ? AND key = ?", rec.UserID, rec.Key).
+			Where(tx.Where("expires_at <= ?", now).
+				Or("completed = ? AND created_at <= ?", false, now.Add(-lease))).
+			Delete(&models.IdempotencyKey{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rec)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			reserved = true
+			return nil
+		}
+
+		return tx.Where("user_id = ? AND key = ?", rec.UserID, rec.Key).First(&existing).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.IdempotencyKey{}, false, errs.ErrNotFound
+		}
+		return models.IdempotencyKey{}, false, fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+	}
+
+	if reserved {
+		return rec, true, nil
+	}
+	return existing, false, nil
+}
+
+func (r *idempotencyRepository) Complete(
+	userID uint,
+	key string,
+	status int,
+	headers http.Header,
+	body []byte,
+) error {
+	// a struct update, so headers go through the JSON serializer
+	result := r.db.Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND key = ?", userID, key).
+		Select("completed", "status_code", "headers", "response").
+		Updates(&models.IdempotencyKey{
+			Completed:  true,
+			StatusCode: status,
+			Headers:    headers,
+			Response:   body,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", errs.ErrDBOperation, result.Error)
+	}
+
+	return nil
+}
+
+func (r *idempotencyRepository) Release(userID uint, key string) error {
+	result := r.db.Where("user_id = ? AND key = ? AND completed = ?", userID, key, false).
+		Delete(&models.IdempotencyKey{})
+
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", errs.ErrDBOperation, result.Error)
+	}
+
+	return nil
+}
+
+// DeleteExpired drops keys past their expiry together with the worker
+// markers older than ttl
+func (r *idempotencyRepository) DeleteExpired(now time.Time, ttl time.Duration) error {
+	if err := r.db.Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+	}
+
+	if err := r.db.Where("created_at <= ?", now.Add(-ttl)).Delete(&models.ProcessedRequest{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+	}
+
+	return nil
+}
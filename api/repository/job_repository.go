@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type JobRepository interface {
+	Create(job *models.Job) error
+	Complete(id, status string, code errs.Code, message string, result []byte) error
+	Get(userID uint, id string) (models.Job, error)
+	Delete(id string) error
+	DeleteOlder(before time.Time) error
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) Create(job *models.Job) error {
+	if err := r.db.Create(job).Error; err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+	}
+	return nil
+}
+
+// Complete stores the reply of a pending job. Replies of jobs that are
+// unknown or already finished change nothing
+func (r *jobRepository) Complete(
+	id, status string,
+	code errs.Code,
+	message string,
+	result []byte,
+) error {
+	err := r.db.Model(&models.Job{}).
+		Where("id = ? AND status = ?", id, models.JobPending).
+		Updates(map[string]interface{}{
+			"status":        status,
+			"error_code":    code,
+			"error_message": message,
+			"result":        result,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+	}
+	return nil
+}
+
+func (r *jobRepository) Get(userID uint, id string) (models.Job, error) {
+	var job models.Job
+
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Job{}, errs.ErrNotFound
+		}
+		return models.Job{}, fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+	}
+
+	return job, nil
+}
+
+func (r *jobRepository) Delete(id string) error {
+	if err := r.db.Delete(&models.Job{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+	}
+	return nil
+}
+
+func (r *jobRepository) DeleteOlder(before time.Time) error {
+	if err := r.db.Where("created_at < ?", before).Delete(&models.Job{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+	}
+	return nil
+}
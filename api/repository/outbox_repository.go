@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/models"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// outboxLockID is the postgres advisory lock held while a relay claims
+// events, so two relays never claim at once
+const outboxLockID = 72_010_028
+
+type OutboxRepository interface {
+	Claim(limit int, lease time.Duration) (string, []models.OutboxEvent, error)
+	Finish(claim string, sent []uint) error
+	DeleteSent(before time.Time) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// Claim reserves the oldest unsent events for lease and returns them with
+// the ID of the claim. Only one claim is live at a time, so relays of
+// several replicas never send events of one aggregate out of order, and
+// nothing is returned while another relay holds one. The transaction only
+// covers the claim, the events are published after it
+func (r *outboxRepository) Claim(limit int, lease time.Duration) (string, []models.OutboxEvent, error) {
+	claim := uuid.NewString()
+	var events []models.OutboxEvent
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxLockID).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		now := time.Now()
+		var busy bool
+		err := tx.Raw("SELECT EXISTS (SELECT 1 FROM outbox_events WHERE sent_at IS NULL AND claimed_until > ?)", now).
+			Scan(&busy).Error
+		if err != nil || busy {
+			return err
+		}
+
+		result := tx.Where("sent_at IS NULL").Order("id").Limit(limit).Find(&events)
+		if result.Error != nil {
+			return result.Error
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+
+		return tx.Model(&models.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"claimed_by":    claim,
+				"claimed_until": now.Add(lease),
+			}).Error
+	})
+
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+	}
+
+	return claim, events, nil
+}
+
+// Finish marks the sent events of a claim and gives the others back, the
+// next claim publishes them again. Events of a claim that expired and was
+// taken over belong to the new claim and are left alone
+func (r *outboxRepository) Finish(claim string, sent []uint) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if len(sent) > 0 {
+			err := tx.Model(&models.OutboxEvent{}).
+				Where("claimed_by = ? AND id IN ?", claim, sent).
+				Update("sent_at", time.Now()).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.OutboxEvent{}).
+			Where("claimed_by = ?", claim).
+			Updates(map[string]interface{}{
+				"claimed_by":    nil,
+				"claimed_until": nil,
+			}).Error
+	})
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+	}
+
+	return nil
+}
+
+func (r *outboxRepository) DeleteSent(before time.Time) error {
+	result := r.db.Where("sent_at <= ?", before).Delete(&models.OutboxEvent{})
+
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", errs.ErrDBOperation, result.Error)
+	}
+
+	return nil
+}
+
+// appendOutbox stores a domain event in the caller's transaction
+func appendOutbox(
+	tx *gorm.DB,
+	aggregateType string,
+	aggregateID uint,
+	eventType string,
+	data interface{},
+) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   fmt.Sprintf("%s-%d", aggregateType, aggregateID),
+		EventType:     eventType,
+		Payload:       payload,
+	}).Error
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/models"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RateLimitRepository interface {
+	List() ([]models.RateLimitOverride, error)
+	Set(username string, override models.RateLimitOverride) error
+	Delete(username string) error
+}
+
+type rateLimitRepository struct {
+	db *gorm.DB
+}
+
+func NewRateLimitRepository(db *gorm.DB) RateLimitRepository {
+	return &rateLimitRepository{db: db}
+}
+
+// List returns the overrides with their users
+func (r *rateLimitRepository) List() ([]models.RateLimitOverride, error) {
+	var overrides []models.RateLimitOverride
+	result := r.db.Preload("User").Order("user_id").Find(&overrides)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrDBOperation, result.Error)
+	}
+
+	return overrides, nil
+}
+
+// Set creates or replaces the override of the user
+func (r *rateLimitRepository) Set(username string, override models.RateLimitOverride) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		userID, err := findUserID(tx, username)
+		if err != nil {
+			return err
+		}
+
+		override.UserID = userID
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"requests", "period", "burst", "updated_at"}),
+		}).Omit("User").Create(&override)
+
+		if result.Error != nil {
+			return fmt.Errorf("%w: %v", errs.ErrDBOperation, result.Error)
+		}
+
+		return nil
+	})
+}
+
+func (r *rateLimitRepository) Delete(username string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		userID, err := findUserID(tx, username)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("user_id = ?", userID).Delete(&models.RateLimitOverride{})
+
+		if result.Error != nil {
+			return fmt.Errorf("%w: %v", errs.ErrDBOperation, result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return errs.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+func findUserID(tx *gorm.DB, username string) (uint, error) {
+	var user models.User
+	result := tx.Select("id").Where("username = ?", username).Limit(1).Find(&user)
+
+	if result.Error != nil {
+		return 0, fmt.Errorf("%w: %v", errs.ErrDBOperation, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return 0, errs.ErrNotFound
+	}
+
+	return user.ID, nil
+}
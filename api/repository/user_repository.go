@@ -3,6 +3,7 @@ package repository
 import (
 	"bookstore-api/internal/lib/errs"
 	"bookstore-api/internal/models"
+	"errors"
 	"fmt"
 
 	"gorm.io/gorm"
@@ -13,6 +14,8 @@ type UserRepository interface {
 	GetAllUsers() ([]models.User, error)
 	GetByUsername(string) (models.User, error)
 	DeleteByUsername(string) error
+	GetLocale(uint) (string, error)
+	SetLocale(uint, string) error
 }
 
 type userRepository struct {
@@ -52,6 +55,9 @@ func (r *userRepository) GetByUsername(username string) (models.User, error) {
 	var user models.User
 	result := r.db.Where("username = ?", username).First(&user)
 
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.User{}, errs.ErrNotAuthorized
+	}
 	if result.Error != nil {
 		return models.User{}, fmt.Errorf("%w: %v", errs.ErrDBOperation, result.Error)
 	}
@@ -63,8 +69,23 @@ func (r *userRepository) GetByUsername(username string) (models.User, error) {
 	return user, nil
 }
 
-func (r *userRepository) DeleteByUsername(username string) error {
-	result := r.db.Unscoped().Where("username = ?", username).Delete(&models.User{})
+func (r *userRepository) GetLocale(userID uint) (string, error) {
+	var user models.User
+	result := r.db.Select("locale").Where("id = ?", userID).Limit(1).Find(&user)
+
+	if result.Error != nil {
+		return "", fmt.Errorf("%w: %v", errs.ErrDBOperation, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return "", errs.ErrNotFound
+	}
+
+	return user.Locale, nil
+}
+
+func (r *userRepository) SetLocale(userID uint, locale string) error {
+	result := r.db.Model(&models.User{}).Where("id = ?", userID).Update("locale", locale)
 
 	if result.Error != nil {
 		return fmt.Errorf("%w: %v", errs.ErrDBOperation, result.Error)
@@ -76,3 +97,43 @@ func (r *userRepository) DeleteByUsername(username string) error {
 
 	return nil
 }
+
+func (r *userRepository) DeleteByUsername(username string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		result := tx.Where("username = ?", username).Limit(1).Find(&user)
+
+		if result.Error != nil {
+			return fmt.Errorf("%w: %v", errs.ErrDBOperation, result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return errs.ErrNotFound
+		}
+
+		// the books go with the user by ON DELETE CASCADE, their consumers
+		// get a BookDeleted for each like for a single deletion
+		var bookIDs []uint
+		if err := tx.Model(&models.Book{}).Where("user_id = ?", user.ID).Order("id").Pluck("id", &bookIDs).Error; err != nil {
+			return fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+		}
+
+		if err := tx.Unscoped().Delete(&user).Error; err != nil {
+			return fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+		}
+
+		for _, id := range bookIDs {
+			event := models.DeleteBook{ID: id, UserID: user.ID}
+			if err := appendOutbox(tx, "book", id, models.BookDeletedEvent, event); err != nil {
+				return fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+			}
+		}
+
+		deleted := models.UserDeleted{ID: user.ID, Username: user.Username}
+		if err := appendOutbox(tx, "user", user.ID, models.UserDeletedEvent, deleted); err != nil {
+			return fmt.Errorf("%w: %v", errs.ErrDBOperation, err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/reqctx"
+	"bookstore-api/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// GetBookHistory returns the revisions of the book oldest first, each with
+// the fields it changed since the revision before
+func (s *bookService) GetBookHistory(
+	ctx context.Context,
+	userID_iface interface{},
+	bookIDStr string,
+) (models.BookHistoryResponse, error) {
+	userID := interface_into_uint(userID_iface)
+
+	bookID, err := strconv.Atoi(bookIDStr)
+	if err != nil || bookID <= 0 {
+		return models.BookHistoryResponse{}, fmt.Errorf("%w: invalid ID in request %v", errs.ErrInvalidID, err)
+	}
+
+	revisions, err := s.history(ctx, userID, uint(bookID))
+	if err != nil {
+		return models.BookHistoryResponse{}, err
+	}
+
+	history := make([]models.RevisionResponse, len(revisions))
+	for i, rev := range revisions {
+		var prev *models.BookRevision
+		if i > 0 {
+			prev = &revisions[i-1]
+		}
+
+		history[i] = models.RevisionResponse{
+			Revision:  rev.ID,
+			Version:   rev.Version,
+			Operation: rev.Operation,
+			Title:     rev.Title,
+			Author:    rev.Author,
+			Price:     rev.Price,
+			Actor:     rev.Actor,
+			Reason:    rev.Reason,
+			CreatedAt: rev.CreatedAt,
+			Changes:   diffRevisions(prev, rev),
+		}
+	}
+
+	return models.BookHistoryResponse{BookID: uint(bookID), Data: history}, nil
+}
+
+// RevertBook sets the fields of the book back to a revision. The worker
+// reads the revision and applies it in one transaction: an existing book is
+// updated at version and gets a new revision, a deleted one is recreated
+// under its ID
+func (s *bookService) RevertBook(
+	ctx context.Context,
+	userID_iface interface{},
+	bookIDStr string,
+	revisionStr string,
+	version uint,
+) (models.Book, *models.Job, error) {
+	userID := interface_into_uint(userID_iface)
+
+	bookID, err := strconv.Atoi(bookIDStr)
+	if err != nil || bookID <= 0 {
+		return models.Book{}, nil, fmt.Errorf("%w: invalid ID in request %v", errs.ErrInvalidID, err)
+	}
+
+	revisionID, err := strconv.ParseUint(revisionStr, 10, 0)
+	if err != nil || revisionID == 0 {
+		return models.Book{}, nil, errs.New(errs.CodeInvalidParam, "revision_invalid")
+	}
+
+	if reqctx.ChangeReason(ctx) == "" {
+		ctx = reqctx.WithChangeReason(ctx, fmt.Sprintf("Revert to revision %d", revisionID))
+	}
+
+	req := models.RevertBook{
+		ID:       uint(bookID),
+		UserID:   userID,
+		Revision: uint(revisionID),
+		Version:  version,
+	}
+
+	result, job, err := s.write(ctx, models.RevertBookMethod, userID, req)
+	if errors.Is(err, errs.ErrNotFound) {
+		return models.Book{}, nil, errs.Wrap(errs.CodeNotFound, "revision_not_found", err)
+	}
+	if err != nil {
+		return models.Book{}, nil, s.conflictError(result, err)
+	}
+	if job != nil || len(result) == 0 {
+		return models.Book{}, job, nil
+	}
+
+	reverted, err := s.decodeBook(result)
+	return reverted, nil, err
+}
+
+func (s *bookService) history(ctx context.Context, userID, bookID uint) ([]models.BookRevision, error) {
+	req := models.GetBookHistoryRequest{
+		ID:     bookID,
+		UserID: userID,
+	}
+
+	result, err := s.roundTrip(ctx, models.GetBookHistoryMethod, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r models.GetBookHistoryResponse
+	if err := s.codec.Unmarshal(result, &r); err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrInternal, err)
+	}
+
+	return r.Revisions, nil
+}
+
+// diffRevisions lists the fields rev changed, every field for the first
+// revision and none for a deletion
+func diffRevisions(prev *models.BookRevision, rev models.BookRevision) []models.FieldChange {
+	changes := []models.FieldChange{}
+	if rev.Operation == models.RevisionDelete {
+		return changes
+	}
+
+	if prev == nil {
+		return append(changes,
+			models.FieldChange{Field: "title", To: rev.Title},
+			models.FieldChange{Field: "author", To: rev.Author},
+			models.FieldChange{Field: "price", To: rev.Price},
+		)
+	}
+
+	if prev.Title != rev.Title {
+		changes = append(changes, models.FieldChange{Field: "title", From: prev.Title, To: rev.Title})
+	}
+	if prev.Author != rev.Author {
+		changes = append(changes, models.FieldChange{Field: "author", From: prev.Author, To: rev.Author})
+	}
+	if prev.Price != rev.Price {
+		changes = append(changes, models.FieldChange{Field: "price", From: prev.Price, To: rev.Price})
+	}
+
+	return changes
+}
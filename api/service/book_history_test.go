@@ -0,0 +1,81 @@
+package service
+
+import (
+	"bookstore-api/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestDiffRevisions(t *testing.T) {
+	base := models.BookRevision{Operation: models.RevisionUpdate, Title: "Dune", Author: "Herbert", Price: 10}
+	with := func(f func(*models.BookRevision)) models.BookRevision {
+		rev := base
+		f(&rev)
+		return rev
+	}
+
+	tests := []struct {
+		name string
+		prev *models.BookRevision
+		rev  models.BookRevision
+		want []models.FieldChange
+	}{
+		{
+			name: "first revision lists every field",
+			rev:  with(func(r *models.BookRevision) { r.Operation = models.RevisionCreate }),
+			want: []models.FieldChange{
+				{Field: "title", To: "Dune"},
+				{Field: "author", To: "Herbert"},
+				{Field: "price", To: uint(10)},
+			},
+		},
+		{
+			name: "changed fields only",
+			prev: &base,
+			rev:  with(func(r *models.BookRevision) { r.Price = 12 }),
+			want: []models.FieldChange{{Field: "price", From: uint(10), To: uint(12)}},
+		},
+		{
+			name: "several fields in order",
+			prev: &base,
+			rev: with(func(r *models.BookRevision) {
+				r.Title = "Dune Messiah"
+				r.Author = "F. Herbert"
+			}),
+			want: []models.FieldChange{
+				{Field: "title", From: "Dune", To: "Dune Messiah"},
+				{Field: "author", From: "Herbert", To: "F. Herbert"},
+			},
+		},
+		{
+			name: "nothing changed",
+			prev: &base,
+			rev:  base,
+			want: []models.FieldChange{},
+		},
+		{
+			name: "deletion has no changes",
+			prev: &base,
+			rev:  with(func(r *models.BookRevision) { r.Operation = models.RevisionDelete }),
+			want: []models.FieldChange{},
+		},
+		{
+			name: "restore compares with the deleted state",
+			prev: &base,
+			rev: with(func(r *models.BookRevision) {
+				r.Operation = models.RevisionRestore
+				r.Title = "Children of Dune"
+			}),
+			want: []models.FieldChange{{Field: "title", From: "Dune", To: "Children of Dune"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffRevisions(tt.prev, tt.rev)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffRevisions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
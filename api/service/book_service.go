@@ -2,10 +2,17 @@ package service
 
 import (
 	"bookstore-api/api/repository"
+	"bookstore-api/internal/cache"
+	"bookstore-api/internal/lib/codec"
 	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/reqctx"
+	"bookstore-api/internal/lib/sl"
+	"bookstore-api/internal/metrics"
 	"bookstore-api/internal/models"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
 	"sync"
 	"time"
@@ -15,76 +22,85 @@ import (
 )
 
 type BookService interface {
-	GetAllBooks() ([]models.UserBooksResponse, error)
-	GetUserBooks(interface{}, string, string, string) ([]models.Book, uint, error)
-	PostBook(interface{}, models.BookRequest) error
-	UpdateBook(interface{}, string, models.BookRequest) error
-	DeleteBook(interface{}, string) error
+	GetAllBooks(context.Context) ([]models.UserBooksResponse, error)
+	GetUserBooks(context.Context, interface{}, string, string, string) ([]models.Book, uint, error)
+	GetBook(context.Context, interface{}, string) (models.Book, error)
+	PostBook(context.Context, interface{}, models.BookRequest) (*models.Job, error)
+	UpdateBook(context.Context, interface{}, string, models.BookRequest, uint) (models.Book, *models.Job, error)
+	DeleteBook(context.Context, interface{}, string, uint) (*models.Job, error)
+	GetBookHistory(context.Context, interface{}, string) (models.BookHistoryResponse, error)
+	RevertBook(context.Context, interface{}, string, string, uint) (models.Book, *models.Job, error)
+	BatchBooks(context.Context, interface{}, models.BatchRequest) (models.BatchBookResponse, *models.Job, error)
+	GetJob(context.Context, interface{}, string) (models.Job, error)
+	Ping(context.Context) error
 }
 
+// ReplyTimeout bounds the wait for the reply of the worker
+const ReplyTimeout = 30 * time.Second
+
+const (
+	// maxBatchSize keeps one batch well inside the reply timeout
+	maxBatchSize = 1000
+
+	jobRetention = 24 * time.Hour
+)
+
 type bookService struct {
-	repo      repository.BookRepository
-	producer  *kafka.Producer
-	consumer  *kafka.Consumer
-	topic     string
-	responses sync.Map
+	producer     *kafka.Producer
+	consumer     *kafka.Consumer
+	requestTopic string
+	replyTopic   string
+	codec        codec.Codec
+	jobs         repository.JobRepository
+	books        *booksCache
+	responses    sync.Map
 }
 
+// NewBookService sends book requests to requestTopic and waits for the
+// worker replies on replyTopic. Requests made with respond-async are
+// tracked as jobs instead. Book lists are kept in booksCache for cacheTTL,
+// a nil cache or a zero TTL turns caching off
 func NewBookService(
-	r repository.BookRepository,
 	p *kafka.Producer,
 	c *kafka.Consumer,
-	t string,
+	requestTopic, replyTopic string,
+	cd codec.Codec,
+	jobs repository.JobRepository,
+	booksCache cache.Cache,
+	cacheTTL time.Duration,
 ) BookService {
 	s := &bookService{
-		repo:     r,
-		producer: p,
-		consumer: c,
-		topic:    t,
+		producer:     p,
+		consumer:     c,
+		requestTopic: requestTopic,
+		replyTopic:   replyTopic,
+		codec:        cd,
+		jobs:         jobs,
+		books:        newBooksCache(booksCache, cacheTTL),
 	}
 
-	go s.consumptionMessage()
+	go s.consumeReplies()
+	go s.cleanupJobs()
 
 	return s
 }
 
-func (s *bookService) GetAllBooks() ([]models.UserBooksResponse, error) {
-	books, err := s.repo.GetAllBooks()
+func (s *bookService) GetAllBooks(ctx context.Context) ([]models.UserBooksResponse, error) {
+	result, err := s.roundTrip(ctx, models.GetAllBooksMethod, 0, models.GetAllBooksRequest{})
 	if err != nil {
 		return nil, err
 	}
 
-	usersMap := make(map[string]*models.UserBooksResponse)
-
-	for _, book := range books {
-		username := book.User.Username
-
-		if _, exists := usersMap[username]; !exists {
-			usersMap[username] = &models.UserBooksResponse{
-				Username:   username,
-				TotalBooks: 0,
-				Books:      []models.BookResponse{},
-			}
-		}
-
-		usersMap[username].Books = append(usersMap[username].Books, models.BookResponse{
-			ID:     book.ID,
-			Title:  book.Title,
-			Author: book.Author,
-			Price:  book.Price,
-		})
-		usersMap[username].TotalBooks++
-	}
-
-	result := make([]models.UserBooksResponse, 0, len(usersMap))
-	for _, userBooks := range usersMap {
-		result = append(result, *userBooks)
+	var r models.GetAllBooksResponse
+	if err := s.codec.Unmarshal(result, &r); err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrInternal, err)
 	}
 
-	return result, nil
+	return r.Users, nil
 }
 
 func (s *bookService) GetUserBooks(
+	ctx context.Context,
 	userID_iface interface{},
 	author, title, limitStr string,
 ) ([]models.Book, uint, error) {
@@ -102,14 +118,10 @@ func (s *bookService) GetUserBooks(
 		}
 	}
 
-	relID := uuid.New().String()
-	ch := make(chan models.KafkaBookResponse)
-	s.responses.Store(relID, ch)
-
-	defer func() {
-		s.responses.Delete(relID)
-		close(ch)
-	}()
+	cacheKey := s.books.key(ctx, userID, author, title, limit)
+	if books, ok := s.books.get(ctx, cacheKey); ok {
+		return books, userID, nil
+	}
 
 	payload := models.GetUserBooksRequest{
 		UserID: userID,
@@ -118,224 +130,379 @@ func (s *bookService) GetUserBooks(
 		Limit:  limit,
 	}
 
-	rawMes, err := json.Marshal(payload)
+	result, err := s.roundTrip(ctx, models.GetUserBooksMethod, userID, payload)
 	if err != nil {
-		return nil, 0, fmt.Errorf("%w: %v", errs.ErrInternal, err)
+		return nil, 0, err
 	}
 
-	request := models.KafkaBookRequest{
-		Method:     getUserBooksMethod,
-		Type:       requestType,
-		RelationID: relID,
-		Payload:    json.RawMessage(rawMes),
-	}
-	requestBytes, err := json.Marshal(request)
-	if err != nil {
+	var r models.GetUserBooksResponse
+	if err := s.codec.Unmarshal(result, &r); err != nil {
 		return nil, 0, fmt.Errorf("%w: %v", errs.ErrInternal, err)
 	}
 
-	if err := s.sendKafkaRequest(requestBytes); err != nil {
-		return nil, 0, err
-	}
+	s.books.set(ctx, cacheKey, r.Books)
 
-	select {
-	case resp := <-ch:
-		if resp.Error.Error != "" {
-			if resp.Error.Error == errs.ErrDBOperation.Error() {
-				return nil, 0, fmt.Errorf("%w: %v", errs.ErrDBOperation, resp.Error.Message)
-			}
-			if resp.Error.Error == errs.ErrNotFound.Error() {
-				return nil, 0, fmt.Errorf("%w", errs.ErrNotFound)
-			}
-		}
+	return r.Books, userID, nil
+}
 
-		var r models.GetUserBooksResponse
-		if err := json.Unmarshal(resp.Result, &r); err != nil {
-			return nil, 0, fmt.Errorf("%w: %v", errs.ErrInternal, err)
-		}
+func (s *bookService) GetBook(
+	ctx context.Context,
+	userID_iface interface{},
+	bookIDStr string,
+) (models.Book, error) {
+	userID := interface_into_uint(userID_iface)
 
-		return r.Books, userID, nil
-	case <-time.After(30 * time.Second):
-		return nil, 0, errs.ErrTimeout
+	bookID, err := strconv.Atoi(bookIDStr)
+	if err != nil || bookID <= 0 {
+		return models.Book{}, fmt.Errorf("%w: invalid ID in request %v", errs.ErrInvalidID, err)
 	}
+
+	req := models.GetBookRequest{
+		ID:     uint(bookID),
+		UserID: userID,
+	}
+
+	result, err := s.roundTrip(ctx, models.GetBookMethod, userID, req)
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	return s.decodeBook(result)
 }
 
 func (s *bookService) PostBook(
+	ctx context.Context,
 	userID_iface interface{},
 	input models.BookRequest,
-) error {
+) (*models.Job, error) {
 	userID := interface_into_uint(userID_iface)
 
-	relID := uuid.New().String()
-	ch := make(chan models.KafkaBookResponse)
-	s.responses.Store(relID, ch)
-
-	defer func() {
-		close(ch)
-		s.responses.Delete(relID)
-	}()
-
 	book := models.Book{
 		Title:  input.Title,
 		Author: input.Author,
 		Price:  input.Price,
 		UserID: userID,
 	}
-	rawMes, err := json.Marshal(book)
-	if err != nil {
-		return fmt.Errorf("%w: %v", errs.ErrInternal, err)
+
+	_, job, err := s.write(ctx, models.PostBookMethod, userID, book)
+	return job, err
+}
+
+// UpdateBook changes the book if it is still at version, 0 skips the check.
+// It returns the updated book unless the request was replayed or is async
+func (s *bookService) UpdateBook(
+	ctx context.Context,
+	userID_iface interface{},
+	bookIDStr string,
+	input models.BookRequest,
+	version uint,
+) (models.Book, *models.Job, error) {
+	userID := interface_into_uint(userID_iface)
+
+	bookID, err := strconv.Atoi(bookIDStr)
+	if err != nil || bookID <= 0 {
+		return models.Book{}, nil, fmt.Errorf("%w: invalid ID in request %v", errs.ErrInvalidID, err)
 	}
 
-	request := models.KafkaBookRequest{
-		Method:     postBookMethod,
-		Type:       requestType,
-		RelationID: relID,
-		Payload:    json.RawMessage(rawMes),
+	book := models.Book{
+		ID:      uint(bookID),
+		Title:   input.Title,
+		Author:  input.Author,
+		Price:   input.Price,
+		UserID:  userID,
+		Version: version,
 	}
-	requestBytes, err := json.Marshal(request)
+
+	return s.update(ctx, userID, book)
+}
+
+func (s *bookService) update(ctx context.Context, userID uint, book models.Book) (models.Book, *models.Job, error) {
+	result, job, err := s.write(ctx, models.UpdateBookMethod, userID, book)
 	if err != nil {
-		return fmt.Errorf("%w: %v", errs.ErrInternal, err)
+		return models.Book{}, nil, s.conflictError(result, err)
 	}
-
-	if err := s.sendKafkaRequest(requestBytes); err != nil {
-		return err
+	if job != nil || len(result) == 0 {
+		return models.Book{}, job, nil
 	}
 
-	select {
-	case resp := <-ch:
-		if resp.Error.Error != "" {
-			if resp.Error.Error == errs.ErrDBOperation.Error() {
-				return fmt.Errorf("%w: %v", errs.ErrDBOperation, resp.Error.Message)
-			}
-		}
-		return nil
-	case <-time.After(30 * time.Second):
-		return errs.ErrTimeout
-	}
+	updated, err := s.decodeBook(result)
+	return updated, nil, err
 }
 
-func (s *bookService) UpdateBook(
+// DeleteBook deletes the book if it is still at version, 0 skips the check
+func (s *bookService) DeleteBook(
+	ctx context.Context,
 	userID_iface interface{},
 	bookIDStr string,
-	input models.BookRequest,
-) error {
+	version uint,
+) (*models.Job, error) {
 	userID := interface_into_uint(userID_iface)
 
 	bookID, err := strconv.Atoi(bookIDStr)
 	if err != nil || bookID <= 0 {
-		return fmt.Errorf("%w: invalid ID in request %v", errs.ErrInvalidID, err)
+		return nil, fmt.Errorf("%w: invalid ID in request %v", errs.ErrInvalidID, err)
 	}
 
-	relID := uuid.New().String()
-	ch := make(chan models.KafkaBookResponse)
-	s.responses.Store(relID, ch)
+	req := models.DeleteBook{
+		ID:      uint(bookID),
+		UserID:  userID,
+		Version: version,
+	}
 
-	defer func() {
-		s.responses.Delete(relID)
-		close(ch)
-	}()
+	result, job, err := s.write(ctx, models.DeleteBookMethod, userID, req)
+	if err != nil {
+		return nil, s.conflictError(result, err)
+	}
+	return job, nil
+}
 
-	book := models.Book{
-		ID:     uint(bookID),
-		Title:  input.Title,
-		Author: input.Author,
-		Price:  input.Price,
-		UserID: userID,
+func (s *bookService) BatchBooks(
+	ctx context.Context,
+	userID_iface interface{},
+	input models.BatchRequest,
+) (models.BatchBookResponse, *models.Job, error) {
+	userID := interface_into_uint(userID_iface)
+
+	if len(input.Operations) > maxBatchSize {
+		return models.BatchBookResponse{}, nil, fmt.Errorf(
+			"%w: batch has %d operations, at most %d allowed",
+			errs.ErrInvalidParam, len(input.Operations), maxBatchSize,
+		)
 	}
-	rawMes, err := json.Marshal(book)
-	if err != nil {
-		return fmt.Errorf("%w: %v", errs.ErrInternal, err)
+
+	batch := models.BatchBook{
+		UserID:     userID,
+		Mode:       input.Mode,
+		Operations: make([]models.BatchBookOperation, len(input.Operations)),
+	}
+	if batch.Mode == "" {
+		batch.Mode = models.BatchModeAtomic
 	}
 
-	request := models.KafkaBookRequest{
-		Method:     updateBookMethod,
-		Type:       requestType,
-		RelationID: relID,
-		Payload:    json.RawMessage(rawMes),
+	for i, op := range input.Operations {
+		needsID := op.Op != models.BatchOpCreate
+		needsBook := op.Op != models.BatchOpDelete
+
+		if needsID && op.ID == 0 {
+			return models.BatchBookResponse{}, nil, fmt.Errorf("%w: operation %d: id is required", errs.ErrInvalidID, i)
+		}
+		if needsBook && op.Book == nil {
+			return models.BatchBookResponse{}, nil, fmt.Errorf("%w: operation %d: book is required", errs.ErrInvalidParam, i)
+		}
+
+		book := models.Book{ID: op.ID, UserID: userID, Version: op.Version}
+		if op.Book != nil {
+			book.Title = op.Book.Title
+			book.Author = op.Book.Author
+			book.Price = op.Book.Price
+		}
+		batch.Operations[i] = models.BatchBookOperation{Op: op.Op, Book: book}
 	}
-	requestBytes, err := json.Marshal(request)
-	if err != nil {
-		return fmt.Errorf("%w: %v", errs.ErrInternal, err)
+
+	result, job, err := s.write(ctx, models.BatchBookMethod, userID, batch)
+	if err != nil || job != nil {
+		return models.BatchBookResponse{}, job, err
 	}
 
-	if err := s.sendKafkaRequest(requestBytes); err != nil {
+	var r models.BatchBookResponse
+	if err := s.codec.Unmarshal(result, &r); err != nil {
+		return models.BatchBookResponse{}, nil, fmt.Errorf("%w: %v", errs.ErrInternal, err)
+	}
+
+	return r, nil, nil
+}
+
+func (s *bookService) GetJob(
+	ctx context.Context,
+	userID_iface interface{},
+	jobID string,
+) (models.Job, error) {
+	userID := interface_into_uint(userID_iface)
+
+	if _, err := uuid.Parse(jobID); err != nil {
+		return models.Job{}, fmt.Errorf("%w: invalid job ID %v", errs.ErrInvalidID, err)
+	}
+
+	return s.jobs.Get(userID, jobID)
+}
+
+func (s *bookService) decodeBook(result []byte) (models.Book, error) {
+	var book models.Book
+	if err := s.codec.Unmarshal(result, &book); err != nil {
+		return models.Book{}, fmt.Errorf("%w: %v", errs.ErrInternal, err)
+	}
+	return book, nil
+}
+
+// conflictError attaches the current book the worker sent with a failed
+// version check, so the client can retry without reading it again
+func (s *bookService) conflictError(result []byte, err error) error {
+	if !errors.Is(err, errs.ErrPreconditionFailed) {
 		return err
 	}
 
-	select {
-	case resp := <-ch:
-		if resp.Error.Error != "" {
-			if resp.Error.Error == errs.ErrDBOperation.Error() {
-				return fmt.Errorf("%w: %v", errs.ErrDBOperation, resp.Error.Message)
-			}
-			if resp.Error.Error == errs.ErrNotFound.Error() {
-				return fmt.Errorf("%w", errs.ErrNotFound)
-			}
+	e := errs.Wrap(errs.CodePreconditionFailed, "book_changed", err)
+	if len(result) > 0 {
+		if current, decodeErr := s.decodeBook(result); decodeErr == nil {
+			e.Current = current
 		}
-		return nil
-	case <-time.After(30 * time.Second):
-		return errs.ErrTimeout
 	}
-
+	return e
 }
 
-func (s *bookService) DeleteBook(userID_iface interface{}, bookIDStr string) error {
-	userID := interface_into_uint(userID_iface)
+// Ping sends a request through Kafka that the worker answers without the
+// database, checking the whole request/reply path
+func (s *bookService) Ping(ctx context.Context) error {
+	_, err := s.roundTrip(ctx, models.PingMethod, 0, models.PingRequest{})
+	return err
+}
 
-	bookID, err := strconv.Atoi(bookIDStr)
-	if err != nil || bookID <= 0 {
-		return fmt.Errorf("%w: invalid ID in request %v", errs.ErrInvalidID, err)
+// write waits for the worker reply, or when the client asked for
+// respond-async returns a pending job as soon as the request is in Kafka
+func (s *bookService) write(
+	ctx context.Context,
+	method string,
+	userID uint,
+	payload interface{},
+) ([]byte, *models.Job, error) {
+	if !reqctx.RespondAsync(ctx) {
+		result, err := s.roundTrip(ctx, method, userID, payload)
+		if err == nil {
+			s.books.invalidate(ctx, userID)
+		}
+		return result, nil, err
 	}
 
-	relID := uuid.New().String()
-	ch := make(chan models.KafkaBookResponse)
-	s.responses.Store(relID, ch)
+	job, err := s.submit(ctx, method, userID, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, job, nil
+}
 
-	defer func() {
-		s.responses.Delete(relID)
-		close(ch)
-	}()
+// submit stores a pending job and sends the request. The job is saved first
+// so a fast reply always finds it
+func (s *bookService) submit(
+	ctx context.Context,
+	method string,
+	userID uint,
+	payload interface{},
+) (*models.Job, error) {
+	relID, request, err := s.encodeRequest(ctx, method, userID, payload)
+	if err != nil {
+		return nil, err
+	}
 
-	req := models.DeleteBook{
-		ID:     uint(bookID),
+	job := &models.Job{
+		ID:     relID,
 		UserID: userID,
+		Method: method,
+		Status: models.JobPending,
 	}
-	rawMes, err := json.Marshal(req)
-	if err != nil {
-		return fmt.Errorf("%w: %v", errs.ErrInternal, err)
+	if err := s.jobs.Create(job); err != nil {
+		return nil, err
 	}
 
-	request := models.KafkaBookRequest{
-		Method:     deleteBookMethod,
-		Type:       requestType,
-		RelationID: relID,
-		Payload:    json.RawMessage(rawMes),
+	if err := s.sendKafkaRequest(ctx, method, request, userID); err != nil {
+		if err := s.jobs.Delete(relID); err != nil {
+			slog.ErrorContext(ctx, "service.submit delete job", "id", relID, sl.Error(err))
+		}
+		return nil, err
 	}
-	requestBytes, err := json.Marshal(request)
+
+	return job, nil
+}
+
+// roundTrip sends a request through Kafka and waits for the matching
+// response, returning its result or the error reported by the worker
+func (s *bookService) roundTrip(
+	ctx context.Context,
+	method string,
+	userID uint,
+	payload interface{},
+) (result []byte, err error) {
+	start := time.Now()
+
+	defer func() {
+		outcome := "ok"
+		if err != nil {
+			outcome = "error"
+			if errors.Is(err, errs.ErrTimeout) {
+				outcome = "timeout"
+			}
+			metrics.Errors.WithLabelValues("api", method, string(errs.CodeOf(err))).Inc()
+		}
+		metrics.RoundTrip.WithLabelValues(method, outcome).Observe(time.Since(start).Seconds())
+	}()
+
+	relID, requestBytes, err := s.encodeRequest(ctx, method, userID, payload)
 	if err != nil {
-		return fmt.Errorf("%w: %v", errs.ErrInternal, err)
+		return nil, err
 	}
 
-	if err := s.sendKafkaRequest(requestBytes); err != nil {
-		return err
+	ch := make(chan models.KafkaBookResponse, 1)
+	s.responses.Store(relID, ch)
+	metrics.PendingRequests.Inc()
+	defer func() {
+		s.responses.Delete(relID)
+		metrics.PendingRequests.Dec()
+	}()
+
+	if err := s.sendKafkaRequest(ctx, method, requestBytes, userID); err != nil {
+		return nil, err
 	}
 
 	select {
 	case resp := <-ch:
-		if resp.Error.Error != "" {
-			if resp.Error.Error == errs.ErrDBOperation.Error() {
-				return fmt.Errorf("%w: %v", errs.ErrDBOperation, resp.Error.Message)
-			}
-			if resp.Error.Error == errs.ErrNotFound.Error() {
-				return fmt.Errorf("%w", errs.ErrNotFound)
-			}
+		// a failed write may still carry a result, e.g. the current book
+		if resp.Error != nil {
+			return resp.Result, resp.Error.Err()
 		}
-		return nil
-	case <-time.After(30 * time.Second):
-		return errs.ErrTimeout
+		return resp.Result, nil
+	case <-time.After(ReplyTimeout):
+		return nil, errs.ErrTimeout
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %v", errs.ErrTimeout, ctx.Err())
 	}
 }
 
+// encodeRequest wraps payload into a request envelope with a new relation ID
+func (s *bookService) encodeRequest(
+	ctx context.Context,
+	method string,
+	userID uint,
+	payload interface{},
+) (string, []byte, error) {
+	rawMes, err := s.codec.Marshal(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", errs.ErrInternal, err)
+	}
+
+	relID := uuid.New().String()
+	request := models.KafkaBookRequest{
+		SchemaVersion:  models.KafkaSchemaVersion,
+		MessageID:      uuid.New().String(),
+		Method:         method,
+		Type:           models.KafkaRequestType,
+		RelationID:     relID,
+		UserID:         userID,
+		IdempotencyKey: reqctx.IdempotencyKey(ctx),
+		RequestID:      reqctx.RequestID(ctx),
+		Actor:          reqctx.Actor(ctx),
+		Reason:         reqctx.ChangeReason(ctx),
+		SentAt:         time.Now().UTC(),
+		Payload:        rawMes,
+	}
+	requestBytes, err := s.codec.Marshal(request)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", errs.ErrInternal, err)
+	}
+
+	slog.DebugContext(ctx, "kafka request", "method", method, "relation_id", relID)
+
+	return relID, requestBytes, nil
+}
+
 func interface_into_uint(userID_iface interface{}) uint {
 	var userID uint
 	switch v := userID_iface.(type) {
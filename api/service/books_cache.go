@@ -0,0 +1,148 @@
+package service
+
+import (
+	"bookstore-api/internal/cache"
+	"bookstore-api/internal/lib/sl"
+	"bookstore-api/internal/metrics"
+	"bookstore-api/internal/models"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// booksCache keeps book lists of users. Every user has a generation that is
+// part of the keys of their lists, invalidating replaces it, so the old
+// lists are never read again and age out. That needs no key scans and
+// works the same on a shared cache. A nil booksCache caches nothing
+type booksCache struct {
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+func newBooksCache(c cache.Cache, ttl time.Duration) *booksCache {
+	if c == nil || ttl <= 0 {
+		return nil
+	}
+	return &booksCache{cache: c, ttl: ttl}
+}
+
+// booksKey names the list of a user for a query under the generation the
+// read started with
+type booksKey struct {
+	userID     uint
+	generation string
+	key        string
+}
+
+// key names the list of the user for a query. Empty parameters are left
+// out and url.Values sorts the rest, so equal queries share a key
+func (b *booksCache) key(ctx context.Context, userID uint, author, title string, limit int) booksKey {
+	if b == nil {
+		return booksKey{}
+	}
+
+	q := url.Values{}
+	if author != "" {
+		q.Set("author", author)
+	}
+	if title != "" {
+		q.Set("title", title)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	gen := b.generation(ctx, userID)
+	return booksKey{
+		userID:     userID,
+		generation: gen,
+		key:        "books:" + strconv.FormatUint(uint64(userID), 10) + ":" + gen + ":" + q.Encode(),
+	}
+}
+
+func (b *booksCache) get(ctx context.Context, key booksKey) ([]models.Book, bool) {
+	if b == nil {
+		return nil, false
+	}
+
+	data, ok, err := b.cache.Get(ctx, key.key)
+	if err != nil {
+		slog.WarnContext(ctx, "service.booksCache.get", sl.Error(err))
+		metrics.CacheLookups.WithLabelValues("books", "error").Inc()
+		return nil, false
+	}
+	if !ok {
+		metrics.CacheLookups.WithLabelValues("books", "miss").Inc()
+		return nil, false
+	}
+
+	var books []models.Book
+	if err := json.Unmarshal(data, &books); err != nil {
+		slog.WarnContext(ctx, "service.booksCache.get", sl.Error(err))
+		metrics.CacheLookups.WithLabelValues("books", "error").Inc()
+		return nil, false
+	}
+
+	metrics.CacheLookups.WithLabelValues("books", "hit").Inc()
+	return books, true
+}
+
+// set stores the list unless the user was invalidated since the read
+// started, the list may then predate the write and would only take space
+func (b *booksCache) set(ctx context.Context, key booksKey, books []models.Book) {
+	if b == nil {
+		return
+	}
+
+	gen, ok, err := b.cache.Get(ctx, b.generationKey(key.userID))
+	if err == nil && (!ok || string(gen) != key.generation) {
+		return
+	}
+
+	data, err := json.Marshal(books)
+	if err == nil {
+		err = b.cache.Set(ctx, key.key, data, b.ttl)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "service.booksCache.set", sl.Error(err))
+	}
+}
+
+// invalidate makes the cached lists of the user stale
+func (b *booksCache) invalidate(ctx context.Context, userID uint) {
+	if b == nil {
+		return
+	}
+
+	if err := b.cache.Set(ctx, b.generationKey(userID), []byte(uuid.NewString()), 0); err != nil {
+		slog.WarnContext(ctx, "service.booksCache.invalidate", "user_id", userID, sl.Error(err))
+		return
+	}
+	metrics.CacheInvalidations.WithLabelValues("books").Inc()
+}
+
+// generation returns the current generation of the user, starting a new
+// one when it is unknown or was evicted
+func (b *booksCache) generation(ctx context.Context, userID uint) string {
+	key := b.generationKey(userID)
+
+	gen, ok, err := b.cache.Get(ctx, key)
+	if err == nil && ok {
+		return string(gen)
+	}
+
+	fresh := uuid.NewString()
+	if err := b.cache.Set(ctx, key, []byte(fresh), 0); err != nil {
+		slog.WarnContext(ctx, "service.booksCache.generation", "user_id", userID, sl.Error(err))
+	}
+	return fresh
+}
+
+func (b *booksCache) generationKey(userID uint) string {
+	return "books:" + strconv.FormatUint(uint64(userID), 10) + ":generation"
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"bookstore-api/internal/cache"
+	"bookstore-api/internal/models"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBooksCache(t *testing.T) {
+	ctx := context.Background()
+	books := []models.Book{{ID: 1, Title: "Война и мир", UserID: 7}}
+
+	tests := []struct {
+		name string
+		// between runs between taking the key and storing the list
+		between func(b *booksCache)
+		cached  bool
+	}{
+		{
+			name:    "stored",
+			between: func(*booksCache) {},
+			cached:  true,
+		},
+		{
+			name:    "invalidated during the read",
+			between: func(b *booksCache) { b.invalidate(ctx, 7) },
+			cached:  false,
+		},
+		{
+			name:    "another user invalidated",
+			between: func(b *booksCache) { b.invalidate(ctx, 8) },
+			cached:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBooksCache(cache.NewLRU(100), time.Minute)
+
+			key := b.key(ctx, 7, "Толстой", "", 10)
+			tt.between(b)
+			b.set(ctx, key, books)
+
+			_, ok := b.get(ctx, b.key(ctx, 7, "Толстой", "", 10))
+			if ok != tt.cached {
+				t.Fatalf("cached = %v, want %v", ok, tt.cached)
+			}
+		})
+	}
+}
+
+func TestBooksCacheInvalidate(t *testing.T) {
+	ctx := context.Background()
+	b := newBooksCache(cache.NewLRU(100), time.Minute)
+
+	key := b.key(ctx, 7, "", "", 0)
+	b.set(ctx, key, []models.Book{{ID: 1}})
+	if _, ok := b.get(ctx, key); !ok {
+		t.Fatal("list was not cached")
+	}
+
+	b.invalidate(ctx, 7)
+
+	if _, ok := b.get(ctx, b.key(ctx, 7, "", "", 0)); ok {
+		t.Fatal("list is served after invalidation")
+	}
+}
+
+func TestBooksCacheKey(t *testing.T) {
+	ctx := context.Background()
+	b := newBooksCache(cache.NewLRU(100), time.Minute)
+
+	if a, c := b.key(ctx, 7, "a", "t", 5), b.key(ctx, 7, "a", "t", 5); a != c {
+		t.Fatalf("equal queries got keys %q and %q", a.key, c.key)
+	}
+	if a, c := b.key(ctx, 7, "a", "", 0), b.key(ctx, 7, "", "a", 0); a == c {
+		t.Fatalf("author and title share key %q", a.key)
+	}
+
+	if newBooksCache(nil, time.Minute) != nil || newBooksCache(cache.NewLRU(1), 0) != nil {
+		t.Fatal("disabled cache is not nil")
+	}
+}
@@ -1,259 +1,170 @@
 package service
 
 import (
+	"bookstore-api/internal/lib/codec"
 	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/reqctx"
+	"bookstore-api/internal/lib/schema"
+	"bookstore-api/internal/lib/sl"
 	"bookstore-api/internal/models"
-	"encoding/json"
-	"fmt"
+	cons "bookstore-api/internal/perskafka/consumer"
+	prod "bookstore-api/internal/perskafka/producer"
+	"bookstore-api/internal/tracing"
+	"context"
 	"log"
+	"log/slog"
+	"strconv"
+	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const (
-	getAllBooksMethod  = "GetAllBooksMethod"
-	getUserBooksMethod = "GetUserBooks"
-	postBookMethod     = "PostBookMethod"
-	updateBookMethod   = "UpdateBookMethod"
-	deleteBookMethod   = "DeleteBookMethod"
+// sendKafkaRequest keys the request by user ID, so all requests of a user
+// share a partition and keep their order. The trace context travels in the
+// message headers
+func (s *bookService) sendKafkaRequest(ctx context.Context, method string, req []byte, userID uint) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "send "+s.requestTopic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(s.requestTopic),
+			attribute.String("bookstore.method", method),
+		),
+	)
+	defer func() { tracing.End(span, err) }()
 
-	requestType  = "request"
-	responseType = "response"
-)
-
-func (s *bookService) sendKafkaRequest(req []byte) error {
-	kafkaMsg := &kafka.Message{
+	msg := &kafka.Message{
 		TopicPartition: kafka.TopicPartition{
-			Topic:     &s.topic,
+			Topic:     &s.requestTopic,
 			Partition: kafka.PartitionAny,
 		},
-		Value: req,
-		Key:   nil,
-	}
-
-	deliveryChan := make(chan kafka.Event)
-	defer close(deliveryChan)
-
-	if err := s.producer.Produce(kafkaMsg, deliveryChan); err != nil {
-		return fmt.Errorf("%w: %v", errs.ErrKafkaProducer, err)
-	}
-
-	e := <-deliveryChan
-	switch event := e.(type) {
-	case *kafka.Message:
-		if event.TopicPartition.Error != nil {
-			return fmt.Errorf("%w: %v", errs.ErrKafkaProducer, event.TopicPartition.Error)
-		}
-		return nil
-	case *kafka.Error:
-		return fmt.Errorf("%w: %v", errs.ErrKafkaProducer, event)
+		Value:   req,
+		Key:     []byte(strconv.FormatUint(uint64(userID), 10)),
+		Headers: codec.Headers(s.codec),
 	}
+	tracing.InjectKafka(ctx, msg)
 
-	return nil
+	return prod.Send(s.producer, msg)
 }
 
-func (s *bookService) consumptionMessage() {
-	if err := s.consumer.Subscribe(s.topic, nil); err != nil {
-		log.Println("service/kafka.go -> 44 line", err)
+func (s *bookService) consumeReplies() {
+	// without replies every request would time out, stop instead
+	if err := s.consumer.Subscribe(s.replyTopic, nil); err != nil {
+		log.Fatalln("service/kafka.go |", err)
 	}
 	defer s.consumer.Close()
 
-	semaphore := make(chan struct{}, 10)
-
 	for {
-		msg, err := s.consumer.ReadMessage(-1)
+		msg, err := cons.Read(s.consumer)
 		if err != nil {
-			log.Println("service/kafka.go -> 53 line |", err)
+			log.Println("service/kafka.go |", err)
 		}
 		if msg == nil {
 			log.Println("Received nil message, skip")
 			continue
 		}
 
-		var msgCommon struct {
-			Method     string `json:"method"`
-			Type       string `json:"type"`
-			RelationID string `json:"relation_id"`
-		}
-
-		if err := json.Unmarshal(msg.Value, &msgCommon); err != nil {
-			log.Println("service/kafka.go -> 66 line |", err)
+		cd, env, err := codec.DecodeEnvelope(msg)
+		if err != nil {
+			log.Println("service/kafka.go | skip message", msg.TopicPartition, err)
+			continue
 		}
 
-		switch msgCommon.Type {
-		case requestType:
-			go func() {
-				semaphore <- struct{}{}
-				defer func() {
-					<-semaphore
-				}()
-
-				if err := s.proccessRequest(msg, msgCommon.Method); err != nil {
-					log.Println("service/kafka.go -> 88 line |", err)
-				}
-			}()
-		case responseType:
-			var res models.KafkaBookResponse
-			if err := json.Unmarshal(msg.Value, &res); err != nil {
-				log.Println("service/kafka.go -> 100 |", err)
-			}
-
-			if ch, ok := s.responses.Load(res.RelationID); ok {
-				select {
-				case ch.(chan models.KafkaBookResponse) <- res:
-				default:
-					log.Println("service/kafka.go -> 108 | Could not sent chan")
-				}
-			} else {
-				log.Println("Channel not found for RelationID:", res.RelationID)
-			}
-
+		if env.Type == models.KafkaResponseType {
+			s.dispatchResponse(msg, cd, env.RelationID)
 		}
 	}
 }
 
-func (s *bookService) proccessRequest(msg *kafka.Message, method string) error {
-	var kafkaReq struct {
-		Method     string          `json:"method"`
-		RelationID string          `json:"relation_id"`
-		Payload    json.RawMessage `json:"payload"`
-	}
-	if err := json.Unmarshal(msg.Value, &kafkaReq); err != nil {
-		return fmt.Errorf("%w: %v", errs.ErrInternal, err)
-	}
-
-	switch method {
-	case getUserBooksMethod:
-		var req models.GetUserBooksRequest
-		if err := json.Unmarshal(kafkaReq.Payload, &req); err != nil {
-			return fmt.Errorf("%w: %v", errs.ErrInternal, err)
-		}
+func (s *bookService) dispatchResponse(msg *kafka.Message, cd codec.Codec, relID string) {
+	var res models.KafkaBookResponse
 
-		books, errKafka := s.repo.GetUserBooks(req.UserID, req.Author, req.Title, req.Limit)
-
-		booksRes := models.GetUserBooksResponse{
-			Books: books,
-		}
-
-		rawMes, err := json.Marshal(booksRes)
-		if err != nil {
-			return fmt.Errorf("%w: %v", errs.ErrInternal, err)
-		}
-
-		res := models.KafkaBookResponse{
-			Method:     kafkaReq.Method,
-			Type:       responseType,
-			RelationID: kafkaReq.RelationID,
-			Result:     json.RawMessage(rawMes),
-			Error:      errKafka,
-		}
-
-		resBytes, err := json.Marshal(res)
-		if err != nil {
-			return fmt.Errorf("%w: %v", errs.ErrInternal, err)
-		}
-
-		if err := s.proccessResponse(resBytes); err != nil {
-			return err
-		}
-	case postBookMethod:
-		var req models.Book
-		if err := json.Unmarshal(kafkaReq.Payload, &req); err != nil {
-			return fmt.Errorf("%w: %v", errs.ErrInternal, err)
-		}
-
-		errKafka := s.repo.PostBook(req)
-
-		res := models.KafkaBookResponse{
-			Method:     kafkaReq.Method,
-			Type:       responseType,
-			RelationID: kafkaReq.RelationID,
-			Error:      errKafka,
-		}
-
-		resBytes, err := json.Marshal(res)
-		if err != nil {
-			return fmt.Errorf("%w: %v", errs.ErrInternal, err)
-		}
+	err := codec.Validate(cd, schema.Response, msg.Value)
+	if err == nil {
+		err = cd.Unmarshal(msg.Value, &res)
+	}
+	if err != nil {
+		log.Println("service/kafka.go |", err)
 
-		if err := s.proccessResponse(resBytes); err != nil {
-			return err
-		}
-	case updateBookMethod:
-		var req models.Book
-		if err := json.Unmarshal(kafkaReq.Payload, &req); err != nil {
-			return fmt.Errorf("%w: %v", errs.ErrInternal, err)
+		// still wake up the waiting request instead of letting it time out
+		res = models.KafkaBookResponse{
+			RelationID: relID,
+			Error: &models.KafkaError{
+				Code:    errs.CodeInvalidMsg,
+				Message: err.Error(),
+			},
 		}
+	}
 
-		errKafka := s.repo.UpdateBook(req.UserID, req.ID, req)
-
-		res := models.KafkaBookResponse{
-			Method:     kafkaReq.Method,
-			Type:       responseType,
-			RelationID: kafkaReq.RelationID,
-			Error:      errKafka,
-		}
-		resBytes, err := json.Marshal(res)
-		if err != nil {
-			return fmt.Errorf("%w: %v", errs.ErrInternal, err)
+	// every instance reads every reply, so all of them drop the lists of
+	// the user, whoever sent the request
+	if isWrite(res.Method) && res.Error == nil && len(msg.Key) > 0 {
+		if userID, err := strconv.ParseUint(string(msg.Key), 10, 64); err == nil {
+			s.books.invalidate(context.Background(), uint(userID))
 		}
+	}
 
-		if err := s.proccessResponse(resBytes); err != nil {
-			return err
-		}
-	case deleteBookMethod:
-		var req models.DeleteBook
-		if err := json.Unmarshal(kafkaReq.Payload, &req); err != nil {
-			return fmt.Errorf("%w: %v", errs.ErrInternal, err)
+	// replies of other API instances are read as well, they are not ours
+	if ch, ok := s.responses.Load(relID); ok {
+		select {
+		case ch.(chan models.KafkaBookResponse) <- res:
+		default:
+			log.Println("service/kafka.go | Could not sent chan")
 		}
+		return
+	}
 
-		errKafka := s.repo.DeleteBook(req.UserID, req.ID)
+	// only writes can be asynchronous, reads and pings never have a job
+	if isWrite(res.Method) {
+		s.completeJob(cd, res)
+	}
+}
 
-		res := models.KafkaBookResponse{
-			Method:     kafkaReq.Method,
-			Type:       responseType,
-			RelationID: kafkaReq.RelationID,
-			Error:      errKafka,
-		}
-		resBytes, err := json.Marshal(res)
-		if err != nil {
-			return fmt.Errorf("%w: %v", errs.ErrInternal, err)
-		}
+// completeJob stores the reply if it belongs to an asynchronous request.
+// Every API instance reads every reply, so the update is a no-op for
+// replies of synchronous writes and for jobs another instance completed
+func (s *bookService) completeJob(cd codec.Codec, res models.KafkaBookResponse) {
+	ctx := reqctx.WithRequestID(context.Background(), res.RequestID)
+
+	status := models.JobSucceeded
+	var code errs.Code
+	var message string
+	if res.Error != nil {
+		status = models.JobFailed
+		code = res.Error.Code
+		message = res.Error.Message
+	}
 
-		if err := s.proccessResponse(resBytes); err != nil {
-			return err
-		}
+	result, err := codec.ToJSON(cd, models.NewResult(res.Method), res.Result)
+	if err != nil {
+		slog.ErrorContext(ctx, "service.completeJob", "id", res.RelationID, sl.Error(err))
+		result = nil
 	}
 
-	return nil
+	if err := s.jobs.Complete(res.RelationID, status, code, message, result); err != nil {
+		slog.ErrorContext(ctx, "service.completeJob", "id", res.RelationID, sl.Error(err))
+	}
 }
 
-func (s *bookService) proccessResponse(res []byte) error {
-	msg := &kafka.Message{
-		TopicPartition: kafka.TopicPartition{
-			Topic:     &s.topic,
-			Partition: kafka.PartitionAny,
-		},
-		Value: res,
-		Key:   nil,
+func isWrite(method string) bool {
+	switch method {
+	case models.PostBookMethod, models.UpdateBookMethod, models.DeleteBookMethod, models.RestoreBookMethod,
+		models.BatchBookMethod, models.RevertBookMethod:
+		return true
 	}
+	return false
+}
 
-	deliveryChan := make(chan kafka.Event)
-
-	if err := s.producer.Produce(msg, deliveryChan); err != nil {
-		return fmt.Errorf("%w: %v", errs.ErrInternal, err)
-	}
+func (s *bookService) cleanupJobs() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
 
-	e := <-deliveryChan
-	switch event := e.(type) {
-	case *kafka.Message:
-		if event.TopicPartition.Error != nil {
-			return fmt.Errorf("%w: %v", errs.ErrKafkaProducer, event.TopicPartition.Error)
+	for now := range ticker.C {
+		if err := s.jobs.DeleteOlder(now.Add(-jobRetention)); err != nil {
+			slog.Error("service.cleanupJobs", sl.Error(err))
 		}
-		return nil
-	case kafka.Error:
-		return fmt.Errorf("%w: %v", errs.ErrKafkaProducer, event)
 	}
-
-	return nil
 }
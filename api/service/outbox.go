@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bookstore-api/api/repository"
+	"bookstore-api/internal/lib/sl"
+	"bookstore-api/internal/models"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+const (
+	outboxBatchSize     = 100
+	outboxPollInterval  = time.Second
+	outboxRetentionTime = 24 * time.Hour
+	// outboxDeliveryWait bounds the wait for delivery reports. Events
+	// reported later are sent again with the next claim
+	outboxDeliveryWait = 10 * time.Second
+	// outboxClaimLease is how long a claimed batch stays with its relay. It
+	// outlasts the delivery wait, a relay that stopped meanwhile gives the
+	// batch up when it expires
+	outboxClaimLease = time.Minute
+)
+
+type outboxRelay struct {
+	repo     repository.OutboxRepository
+	producer *kafka.Producer
+	topic    string
+}
+
+// StartOutboxRelay publishes stored domain events to topic in the
+// background. Events of one aggregate share a message key, so they land on
+// one partition in the order they were written
+func StartOutboxRelay(r repository.OutboxRepository, p *kafka.Producer, topic string) {
+	relay := &outboxRelay{
+		repo:     r,
+		producer: p,
+		topic:    topic,
+	}
+
+	go relay.run()
+}
+
+func (r *outboxRelay) run() {
+	poll := time.NewTicker(outboxPollInterval)
+	defer poll.Stop()
+
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-poll.C:
+			r.relay()
+		case now := <-cleanup.C:
+			if err := r.repo.DeleteSent(now.Add(-outboxRetentionTime)); err != nil {
+				slog.Error("service.outboxRelay cleanup", sl.Error(err))
+			}
+		}
+	}
+}
+
+// relay claims a batch, publishes it and records which events were sent.
+// No database transaction is open while the relay waits for the broker
+func (r *outboxRelay) relay() {
+	claim, events, err := r.repo.Claim(outboxBatchSize, outboxClaimLease)
+	if err != nil {
+		slog.Error("service.outboxRelay claim", sl.Error(err))
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	if err := r.repo.Finish(claim, r.publish(events)); err != nil {
+		slog.Error("service.outboxRelay finish", sl.Error(err))
+	}
+}
+
+// publish produces the events and returns the IDs that may be marked as
+// sent: the ones the broker acknowledged, up to the first failure of their
+// aggregate, so a failed event is never overtaken by a later one
+func (r *outboxRelay) publish(events []models.OutboxEvent) []uint {
+	deliveryChan := make(chan kafka.Event, len(events))
+	produced := 0
+
+	for _, event := range events {
+		value, err := json.Marshal(models.DomainEvent{
+			ID:          event.ID,
+			Type:        event.EventType,
+			AggregateID: event.AggregateID,
+			OccurredAt:  event.CreatedAt,
+			Data:        json.RawMessage(event.Payload),
+		})
+		if err != nil {
+			slog.Error("service.outboxRelay marshal", "id", event.ID, sl.Error(err))
+			break
+		}
+
+		msg := &kafka.Message{
+			TopicPartition: kafka.TopicPartition{
+				Topic:     &r.topic,
+				Partition: kafka.PartitionAny,
+			},
+			Key:    []byte(event.AggregateID),
+			Value:  value,
+			Opaque: event.ID,
+			Headers: []kafka.Header{
+				{Key: "event_type", Value: []byte(event.EventType)},
+			},
+		}
+
+		// stop at the first failure so later events of the same aggregate
+		// are not sent ahead of it
+		if err := r.producer.Produce(msg, deliveryChan); err != nil {
+			slog.Error("service.outboxRelay produce", "id", event.ID, sl.Error(err))
+			break
+		}
+		produced++
+	}
+
+	delivered := make(map[uint]bool, produced)
+	timeout := time.NewTimer(outboxDeliveryWait)
+	defer timeout.Stop()
+
+wait:
+	for range produced {
+		select {
+		case ev := <-deliveryChan:
+			m, ok := ev.(*kafka.Message)
+			if !ok {
+				continue
+			}
+			if m.TopicPartition.Error != nil {
+				slog.Error("service.outboxRelay delivery", "id", m.Opaque, sl.Error(m.TopicPartition.Error))
+				continue
+			}
+			delivered[m.Opaque.(uint)] = true
+		case <-timeout.C:
+			slog.Warn("service.outboxRelay delivery timeout", "produced", produced, "delivered", len(delivered))
+			break wait
+		}
+	}
+
+	failed := make(map[string]bool)
+	sent := make([]uint, 0, len(delivered))
+	for _, event := range events {
+		if failed[event.AggregateID] || !delivered[event.ID] {
+			failed[event.AggregateID] = true
+			continue
+		}
+		sent = append(sent, event.ID)
+	}
+
+	return sent
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"bookstore-api/api/repository"
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/sl"
+	"bookstore-api/internal/models"
+	"bookstore-api/internal/ratelimit"
+	"log/slog"
+	"time"
+)
+
+type RateLimitService interface {
+	GetOverrides() ([]models.RateLimitResponse, error)
+	SetOverride(string, models.RateLimitRequest) error
+	DeleteOverride(string) error
+	Reload() error
+	Watch(time.Duration)
+}
+
+type rateLimitService struct {
+	repo    repository.RateLimitRepository
+	limiter *ratelimit.Limiter
+}
+
+// NewRateLimitService keeps the overrides of limiter in sync with the
+// database. limiter is nil when rate limiting is off, the overrides are
+// then only stored
+func NewRateLimitService(repo repository.RateLimitRepository, limiter *ratelimit.Limiter) RateLimitService {
+	return &rateLimitService{repo: repo, limiter: limiter}
+}
+
+func (s *rateLimitService) GetOverrides() ([]models.RateLimitResponse, error) {
+	overrides, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.RateLimitResponse, len(overrides))
+	for i, o := range overrides {
+		res[i] = models.RateLimitResponse{
+			Username: o.User.Username,
+			Requests: o.Requests,
+			Period:   o.Period.String(),
+			Burst:    o.Burst,
+		}
+	}
+
+	return res, nil
+}
+
+func (s *rateLimitService) SetOverride(username string, req models.RateLimitRequest) error {
+	period, err := time.ParseDuration(req.Period)
+	if err != nil || period <= 0 {
+		return errs.New(errs.CodeInvalidParam, "period_invalid")
+	}
+
+	err = s.repo.Set(username, models.RateLimitOverride{
+		Requests:  req.Requests,
+		Period:    period,
+		Burst:     req.Burst,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.reloadAfterChange()
+	return nil
+}
+
+func (s *rateLimitService) DeleteOverride(username string) error {
+	if err := s.repo.Delete(username); err != nil {
+		return err
+	}
+
+	s.reloadAfterChange()
+	return nil
+}
+
+// Reload loads the overrides into the limiter
+func (s *rateLimitService) Reload() error {
+	if s.limiter == nil {
+		return nil
+	}
+
+	overrides, err := s.repo.List()
+	if err != nil {
+		return err
+	}
+
+	limits := make(map[uint]ratelimit.Limit, len(overrides))
+	for _, o := range overrides {
+		limits[o.UserID] = ratelimit.Limit{Requests: o.Requests, Period: o.Period, Burst: o.Burst}
+	}
+	s.limiter.SetOverrides(limits)
+
+	return nil
+}
+
+// Watch reloads the overrides every interval, so changes made through
+// other API instances apply here too
+func (s *rateLimitService) Watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.Reload(); err != nil {
+			slog.Error("service.RateLimit.Watch", sl.Error(err))
+		}
+	}
+}
+
+// the change is saved, a failed reload only delays it until the next tick
+func (s *rateLimitService) reloadAfterChange() {
+	if err := s.Reload(); err != nil {
+		slog.Warn("service.RateLimit.Reload", sl.Error(err))
+	}
+}
@@ -2,25 +2,55 @@ package service
 
 import (
 	"bookstore-api/api/repository"
+	"bookstore-api/internal/cache"
 	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/i18n"
+	"bookstore-api/internal/lib/sl"
 	"bookstore-api/internal/models"
 	"bookstore-api/internal/utils"
+	"context"
 	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
 )
 
+// localeTTL bounds how long an API instance keeps the locale of a user.
+// SetLocale updates the instance that served it, the others see the change
+// after localeTTL at the latest
+const localeTTL = time.Minute
+
 type UserService interface {
 	CreateUser(models.Request) error
 	GetAllUsers() ([]models.UserResponse, error)
 	GetUserToken(models.Request) (string, error)
 	DeleteByUsername(string) error
+	SetLocale(interface{}, string) error
+	Locale(context.Context, uint) (string, error)
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo      repository.UserRepository
+	jwtSecret []byte
+	tokenTTL  time.Duration
+	locales   cache.Cache
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo: repo}
+// NewUserService signs the login tokens with jwtSecret, they expire after
+// tokenTTL. The locales of users are kept in locales, so requests don't
+// read them from the database
+func NewUserService(
+	repo repository.UserRepository,
+	jwtSecret []byte,
+	tokenTTL time.Duration,
+	locales cache.Cache,
+) UserService {
+	return &userService{
+		repo:      repo,
+		jwtSecret: jwtSecret,
+		tokenTTL:  tokenTTL,
+		locales:   locales,
+	}
 }
 
 func (s *userService) CreateUser(creds models.Request) error {
@@ -66,7 +96,7 @@ func (s *userService) GetUserToken(creds models.Request) (string, error) {
 		return "", fmt.Errorf("%w: %v", errs.ErrNotRegistred, err)
 	}
 
-	token, err := utils.GenerateToken(user.ID)
+	token, err := utils.GenerateToken(s.jwtSecret, s.tokenTTL, user.ID)
 	if err != nil {
 		return "", fmt.Errorf("%w: %v", errs.ErrInternal, err)
 	}
@@ -77,3 +107,50 @@ func (s *userService) GetUserToken(creds models.Request) (string, error) {
 func (s *userService) DeleteByUsername(username string) error {
 	return s.repo.DeleteByUsername(username)
 }
+
+// SetLocale overrides Accept-Language for the user, an empty locale removes
+// the override
+func (s *userService) SetLocale(userID_iface interface{}, locale string) error {
+	if locale != "" && !i18n.Supported(locale) {
+		return errs.New(errs.CodeInvalidParam, "locale_unsupported")
+	}
+
+	userID := interface_into_uint(userID_iface)
+	if err := s.repo.SetLocale(userID, locale); err != nil {
+		return err
+	}
+
+	s.cacheLocale(context.Background(), userID, locale)
+	return nil
+}
+
+// Locale returns the locale saved by the user, empty when there is none
+func (s *userService) Locale(ctx context.Context, userID uint) (string, error) {
+	v, ok, err := s.locales.Get(ctx, localeKey(userID))
+	if err != nil {
+		slog.WarnContext(ctx, "service.userService.Locale", "user_id", userID, sl.Error(err))
+	}
+	if ok {
+		return string(v), nil
+	}
+
+	locale, err := s.repo.GetLocale(userID)
+	if err != nil {
+		return "", err
+	}
+
+	s.cacheLocale(ctx, userID, locale)
+	return locale, nil
+}
+
+// cacheLocale keeps empty locales too, users without one are the most
+// frequent
+func (s *userService) cacheLocale(ctx context.Context, userID uint, locale string) {
+	if err := s.locales.Set(ctx, localeKey(userID), []byte(locale), localeTTL); err != nil {
+		slog.WarnContext(ctx, "service.userService.cacheLocale", "user_id", userID, sl.Error(err))
+	}
+}
+
+func localeKey(userID uint) string {
+	return "locale:" + strconv.FormatUint(uint64(userID), 10)
+}
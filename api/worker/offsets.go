@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"sync"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// offsetTracker remembers every message handed to a goroutine, grouped by
+// partition in the order they were read. A partition offset is only
+// reported as committable once all messages before it have finished, so a
+// fast goroutine can never move the committed offset past a slow one.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[partitionKey]*partitionOffsets
+}
+
+type partitionKey struct {
+	topic     string
+	partition int32
+}
+
+type partitionOffsets struct {
+	inFlight []kafka.Offset
+	done     map[kafka.Offset]bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		partitions: make(map[partitionKey]*partitionOffsets),
+	}
+}
+
+func keyOf(tp kafka.TopicPartition) partitionKey {
+	var topic string
+	if tp.Topic != nil {
+		topic = *tp.Topic
+	}
+	return partitionKey{topic: topic, partition: tp.Partition}
+}
+
+// Track registers a message that has been read but not yet processed.
+func (t *offsetTracker) Track(tp kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := keyOf(tp)
+	p, ok := t.partitions[key]
+	if !ok {
+		p = &partitionOffsets{done: make(map[kafka.Offset]bool)}
+		t.partitions[key] = p
+	}
+	p.inFlight = append(p.inFlight, tp.Offset)
+}
+
+// Done marks the message as processed and returns the offset that may be
+// committed for its partition. ok is false when nothing new can be
+// committed yet or the partition is no longer assigned to this consumer.
+func (t *offsetTracker) Done(tp kafka.TopicPartition) (commit kafka.TopicPartition, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, exists := t.partitions[keyOf(tp)]
+	if !exists {
+		return kafka.TopicPartition{}, false
+	}
+	p.done[tp.Offset] = true
+
+	last := kafka.Offset(-1)
+	for len(p.inFlight) > 0 && p.done[p.inFlight[0]] {
+		last = p.inFlight[0]
+		delete(p.done, last)
+		p.inFlight = p.inFlight[1:]
+	}
+	if last < 0 {
+		return kafka.TopicPartition{}, false
+	}
+
+	return kafka.TopicPartition{
+		Topic:     tp.Topic,
+		Partition: tp.Partition,
+		Offset:    last + 1,
+	}, true
+}
+
+// Pending reports whether the message is still tracked and unfinished,
+// false once its partition was revoked
+func (t *offsetTracker) Pending(tp kafka.TopicPartition) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[keyOf(tp)]
+	if !ok || p.done[tp.Offset] {
+		return false
+	}
+	for _, offset := range p.inFlight {
+		if offset == tp.Offset {
+			return true
+		}
+	}
+	return false
+}
+
+// Revoke forgets partitions taken away by a rebalance. Messages still
+// being processed for them will be redelivered to the new owner.
+func (t *offsetTracker) Revoke(partitions []kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tp := range partitions {
+		delete(t.partitions, keyOf(tp))
+	}
+}
@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+func tp(topic string, partition int32, offset kafka.Offset) kafka.TopicPartition {
+	return kafka.TopicPartition{Topic: &topic, Partition: partition, Offset: offset}
+}
+
+func TestOffsetTrackerDone(t *testing.T) {
+	type step struct {
+		done   kafka.Offset
+		commit kafka.Offset // -1 when nothing may be committed
+	}
+
+	tests := []struct {
+		name    string
+		tracked []kafka.Offset
+		steps   []step
+	}{
+		{
+			name:    "in order",
+			tracked: []kafka.Offset{10, 11, 12},
+			steps:   []step{{10, 11}, {11, 12}, {12, 13}},
+		},
+		{
+			name:    "later message waits for the earlier one",
+			tracked: []kafka.Offset{10, 11, 12},
+			steps:   []step{{12, -1}, {11, -1}, {10, 13}},
+		},
+		{
+			name:    "gap in the middle",
+			tracked: []kafka.Offset{10, 11, 12, 13},
+			steps:   []step{{10, 11}, {12, -1}, {13, -1}, {11, 14}},
+		},
+		{
+			name:    "offsets need not be consecutive",
+			tracked: []kafka.Offset{5, 9, 20},
+			steps:   []step{{9, -1}, {5, 10}, {20, 21}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newOffsetTracker()
+			for _, o := range tt.tracked {
+				tr.Track(tp("requests", 0, o))
+			}
+
+			for _, s := range tt.steps {
+				commit, ok := tr.Done(tp("requests", 0, s.done))
+				if s.commit < 0 {
+					if ok {
+						t.Fatalf("Done(%d) = %d, want nothing to commit", s.done, commit.Offset)
+					}
+					continue
+				}
+				if !ok || commit.Offset != s.commit {
+					t.Fatalf("Done(%d) = %d, %v, want %d", s.done, commit.Offset, ok, s.commit)
+				}
+				if *commit.Topic != "requests" || commit.Partition != 0 {
+					t.Fatalf("Done(%d) committed %v", s.done, commit)
+				}
+			}
+		})
+	}
+}
+
+func TestOffsetTrackerPartitionsAreIndependent(t *testing.T) {
+	tr := newOffsetTracker()
+	tr.Track(tp("requests", 0, 1))
+	tr.Track(tp("requests", 1, 1))
+
+	if commit, ok := tr.Done(tp("requests", 1, 1)); !ok || commit.Offset != 2 || commit.Partition != 1 {
+		t.Fatalf("Done on partition 1 = %v, %v", commit, ok)
+	}
+	if !tr.Pending(tp("requests", 0, 1)) {
+		t.Fatal("message of partition 0 is no longer pending")
+	}
+}
+
+func TestOffsetTrackerRevoke(t *testing.T) {
+	tr := newOffsetTracker()
+	tr.Track(tp("requests", 0, 1))
+	tr.Track(tp("requests", 0, 2))
+	tr.Track(tp("requests", 1, 1))
+
+	if !tr.Pending(tp("requests", 0, 2)) {
+		t.Fatal("tracked message is not pending")
+	}
+
+	tr.Revoke([]kafka.TopicPartition{tp("requests", 0, kafka.OffsetInvalid)})
+
+	if tr.Pending(tp("requests", 0, 2)) {
+		t.Fatal("message of a revoked partition is still pending")
+	}
+	if _, ok := tr.Done(tp("requests", 0, 1)); ok {
+		t.Fatal("Done committed a revoked partition")
+	}
+	if _, ok := tr.Done(tp("requests", 1, 1)); !ok {
+		t.Fatal("Done did not commit a partition that was kept")
+	}
+}
+
+func TestOffsetTrackerPending(t *testing.T) {
+	tr := newOffsetTracker()
+	tr.Track(tp("requests", 0, 1))
+	tr.Track(tp("requests", 0, 2))
+
+	tr.Done(tp("requests", 0, 2))
+
+	tests := []struct {
+		offset kafka.Offset
+		want   bool
+	}{
+		{1, true},
+		{2, false}, // done, only waiting for 1
+		{3, false}, // never tracked
+	}
+	for _, tt := range tests {
+		if got := tr.Pending(tp("requests", 0, tt.offset)); got != tt.want {
+			t.Errorf("Pending(%d) = %v, want %v", tt.offset, got, tt.want)
+		}
+	}
+}
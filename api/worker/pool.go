@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"hash/fnv"
+)
+
+// orderedPool runs tasks in parallel across keys but one by one within a
+// key: every key is pinned to the same worker, so requests of one user are
+// executed in the order they were read from the partition
+type orderedPool struct {
+	queues []chan func()
+}
+
+func newOrderedPool(workers, queueSize int) *orderedPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &orderedPool{
+		queues: make([]chan func(), workers),
+	}
+
+	for i := range p.queues {
+		p.queues[i] = make(chan func(), queueSize)
+		go func(queue chan func()) {
+			for task := range queue {
+				task()
+			}
+		}(p.queues[i])
+	}
+
+	return p
+}
+
+// Submit queues the task on the worker owning key. It blocks while that
+// worker's queue is full, which slows down the consumer loop instead of
+// piling up goroutines
+func (p *orderedPool) Submit(key []byte, task func()) {
+	h := fnv.New32a()
+	h.Write(key)
+
+	p.queues[h.Sum32()%uint32(len(p.queues))] <- task
+}
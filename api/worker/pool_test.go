@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"testing"
+)
+
+func TestOrderedPoolKeepsOrderPerKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		keys    int
+		tasks   int
+	}{
+		{"one worker", 1, 3, 100},
+		{"more keys than workers", 4, 16, 100},
+		{"more workers than keys", 8, 2, 100},
+		{"no workers falls back to one", 0, 4, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newOrderedPool(tt.workers, 4)
+
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			got := make(map[string][]int)
+
+			for i := range tt.tasks {
+				for k := range tt.keys {
+					key := fmt.Sprintf("user-%d", k)
+					wg.Add(1)
+					pool.Submit([]byte(key), func() {
+						defer wg.Done()
+						mu.Lock()
+						got[key] = append(got[key], i)
+						mu.Unlock()
+					})
+				}
+			}
+			wg.Wait()
+
+			for key, seq := range got {
+				if len(seq) != tt.tasks {
+					t.Fatalf("%s ran %d tasks, want %d", key, len(seq), tt.tasks)
+				}
+				for i, v := range seq {
+					if v != i {
+						t.Fatalf("%s ran task %d at position %d", key, v, i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestOrderedPoolRunsKeysInParallel(t *testing.T) {
+	pool := newOrderedPool(8, 1)
+
+	// a blocked key must not hold back a key on another worker
+	block := make(chan struct{})
+	var blocked []byte
+	for k := 0; ; k++ {
+		key := []byte(fmt.Sprintf("user-%d", k))
+		if k == 0 {
+			blocked = key
+			pool.Submit(key, func() { <-block })
+			continue
+		}
+		if sameWorker(pool, key, blocked) {
+			continue
+		}
+
+		done := make(chan struct{})
+		pool.Submit(key, func() { close(done) })
+		<-done
+		break
+	}
+	close(block)
+}
+
+func sameWorker(p *orderedPool, a, b []byte) bool {
+	queue := func(key []byte) uint32 {
+		h := fnv.New32a()
+		h.Write(key)
+		return h.Sum32() % uint32(len(p.queues))
+	}
+	return queue(a) == queue(b)
+}
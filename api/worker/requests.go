@@ -0,0 +1,286 @@
+package worker
+
+import (
+	"bookstore-api/internal/lib/codec"
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/reqctx"
+	"bookstore-api/internal/lib/schema"
+	"bookstore-api/internal/lib/sl"
+	"bookstore-api/internal/metrics"
+	"bookstore-api/internal/models"
+	"bookstore-api/internal/tracing"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// proccessRequest executes the request and replies with the codec the
+// request was encoded with. Every request read from the envelope is
+// answered, with an error when it could not be decoded or executed, so the
+// caller never waits for a reply that won't come
+func (w *Worker) proccessRequest(msg *kafka.Message, cd codec.Codec, env models.KafkaEnvelope) (err error) {
+	// continue the trace started by the API request
+	ctx := tracing.ExtractKafka(context.Background(), msg)
+	ctx, span := tracing.Tracer().Start(ctx, "process "+*msg.TopicPartition.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(*msg.TopicPartition.Topic),
+			semconv.MessagingKafkaMessageOffset(int(msg.TopicPartition.Offset)),
+		),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	var result interface{}
+	var errKafka models.KafkaError
+
+	var kafkaReq models.KafkaBookRequest
+	if err := cd.Unmarshal(msg.Value, &kafkaReq); err != nil {
+		// the envelope was readable, that is enough to answer
+		kafkaReq = models.KafkaBookRequest{Method: env.Method, RelationID: env.RelationID}
+		errKafka = models.KafkaError{Code: errs.CodeInvalidMsg, Message: err.Error()}
+	}
+	span.SetAttributes(
+		attribute.String("bookstore.method", kafkaReq.Method),
+		attribute.String("bookstore.relation_id", kafkaReq.RelationID),
+	)
+
+	// log with the request ID of the API request, so both sides line up
+	ctx = reqctx.WithRequestID(ctx, kafkaReq.RequestID)
+	ctx = reqctx.WithActor(ctx, kafkaReq.Actor)
+	ctx = reqctx.WithChangeReason(ctx, kafkaReq.Reason)
+	defer func() {
+		if err != nil {
+			slog.ErrorContext(ctx, "worker.proccessRequest",
+				"method", kafkaReq.Method, "relation_id", kafkaReq.RelationID, sl.Error(err))
+		}
+	}()
+
+	if !kafkaReq.SentAt.IsZero() {
+		metrics.QueueWait.WithLabelValues(kafkaReq.Method).Observe(time.Since(kafkaReq.SentAt).Seconds())
+	}
+
+	res := models.KafkaBookResponse{
+		SchemaVersion: models.KafkaSchemaVersion,
+		MessageID:     uuid.New().String(),
+		Method:        kafkaReq.Method,
+		Type:          models.KafkaResponseType,
+		RelationID:    kafkaReq.RelationID,
+		RequestID:     kafkaReq.RequestID,
+	}
+
+	start := time.Now()
+	if errKafka.Code == "" {
+		if err := codec.Validate(cd, schema.Request, msg.Value); err != nil {
+			errKafka = models.KafkaError{Code: errs.CodeInvalidMsg, Message: err.Error()}
+		} else {
+			result, errKafka = w.executeRequest(ctx, cd, kafkaReq)
+		}
+	}
+	elapsed := time.Since(start)
+	metrics.Processing.WithLabelValues(kafkaReq.Method).Observe(elapsed.Seconds())
+
+	if result != nil {
+		rawMes, err := cd.Marshal(result)
+		if err != nil {
+			errKafka = models.KafkaError{Code: errs.CodeInternal, Message: err.Error()}
+		}
+		res.Result = rawMes
+	}
+
+	if errKafka.Code != "" {
+		metrics.Errors.WithLabelValues("worker", kafkaReq.Method, string(errKafka.Code)).Inc()
+		span.SetAttributes(attribute.String("bookstore.error_code", string(errKafka.Code)))
+		res.Error = &errKafka
+	}
+
+	slog.InfoContext(ctx, "kafka request processed",
+		"method", kafkaReq.Method,
+		"relation_id", kafkaReq.RelationID,
+		"duration", elapsed,
+		"error_code", errKafka.Code,
+	)
+
+	res.SentAt = time.Now().UTC()
+	resBytes, err := cd.Marshal(res)
+	if err != nil {
+		// still answer, without the result that could not be encoded
+		res.Result = nil
+		res.Error = &models.KafkaError{Code: errs.CodeInternal, Message: err.Error()}
+		if resBytes, err = cd.Marshal(res); err != nil {
+			return fmt.Errorf("%w: %v", errs.ErrInternal, err)
+		}
+	}
+
+	return w.reply(ctx, msg.TopicPartition, msg.Key, resBytes, cd)
+}
+
+// executeRequest runs the method against the database and returns the
+// result to send back
+func (w *Worker) executeRequest(
+	ctx context.Context,
+	cd codec.Codec,
+	kafkaReq models.KafkaBookRequest,
+) (interface{}, models.KafkaError) {
+	switch kafkaReq.Method {
+	case models.GetAllBooksMethod:
+		var req models.GetAllBooksRequest
+		if errKafka := decodePayload(cd, schema.GetAllBooks, kafkaReq.Payload, &req); errKafka.Code != "" {
+			return nil, errKafka
+		}
+
+		books, err := w.repo.GetAllBooks(ctx)
+		if err != nil {
+			return nil, models.KafkaError{Code: errs.CodeOf(err), Message: err.Error()}
+		}
+
+		return models.GetAllBooksResponse{Users: groupByUser(books)}, models.KafkaError{}
+	case models.GetUserBooksMethod:
+		var req models.GetUserBooksRequest
+		if errKafka := decodePayload(cd, schema.GetUserBooks, kafkaReq.Payload, &req); errKafka.Code != "" {
+			return nil, errKafka
+		}
+
+		books, errKafka := w.repo.GetUserBooks(ctx, req.UserID, req.Author, req.Title, req.Limit)
+
+		return models.GetUserBooksResponse{Books: books}, errKafka
+	case models.GetBookMethod:
+		var req models.GetBookRequest
+		if errKafka := decodePayload(cd, schema.GetBook, kafkaReq.Payload, &req); errKafka.Code != "" {
+			return nil, errKafka
+		}
+
+		return bookResult(w.repo.GetBook(ctx, req.UserID, req.ID))
+	case models.GetBookHistoryMethod:
+		var req models.GetBookHistoryRequest
+		if errKafka := decodePayload(cd, schema.GetBookHistory, kafkaReq.Payload, &req); errKafka.Code != "" {
+			return nil, errKafka
+		}
+
+		revisions, errKafka := w.repo.GetBookHistory(ctx, req.UserID, req.ID)
+		if errKafka.Code != "" {
+			return nil, errKafka
+		}
+
+		return models.GetBookHistoryResponse{Revisions: revisions}, models.KafkaError{}
+	case models.PostBookMethod:
+		var req models.Book
+		if errKafka := decodePayload(cd, schema.Book, kafkaReq.Payload, &req); errKafka.Code != "" {
+			return nil, errKafka
+		}
+
+		return nil, w.repo.PostBook(ctx, req, kafkaReq.IdempotencyKey)
+	case models.UpdateBookMethod:
+		var req models.Book
+		if errKafka := decodePayload(cd, schema.Book, kafkaReq.Payload, &req); errKafka.Code != "" {
+			return nil, errKafka
+		}
+
+		return bookResult(w.repo.UpdateBook(ctx, req.UserID, req.ID, req, kafkaReq.IdempotencyKey))
+	case models.DeleteBookMethod:
+		var req models.DeleteBook
+		if errKafka := decodePayload(cd, schema.DeleteBook, kafkaReq.Payload, &req); errKafka.Code != "" {
+			return nil, errKafka
+		}
+
+		return bookResult(w.repo.DeleteBook(ctx, req.UserID, req.ID, req.Version, kafkaReq.IdempotencyKey))
+	case models.RestoreBookMethod:
+		var req models.Book
+		if errKafka := decodePayload(cd, schema.Book, kafkaReq.Payload, &req); errKafka.Code != "" {
+			return nil, errKafka
+		}
+
+		return bookResult(w.repo.RestoreBook(ctx, req, kafkaReq.IdempotencyKey))
+	case models.RevertBookMethod:
+		var req models.RevertBook
+		if errKafka := decodePayload(cd, schema.RevertBook, kafkaReq.Payload, &req); errKafka.Code != "" {
+			return nil, errKafka
+		}
+
+		return bookResult(w.repo.RevertBook(ctx, req, kafkaReq.IdempotencyKey))
+	case models.BatchBookMethod:
+		var req models.BatchBook
+		if errKafka := decodePayload(cd, schema.BatchBook, kafkaReq.Payload, &req); errKafka.Code != "" {
+			return nil, errKafka
+		}
+
+		res, errKafka := w.repo.BatchBooks(ctx, req, kafkaReq.IdempotencyKey)
+		if errKafka.Code != "" {
+			return nil, errKafka
+		}
+
+		return res, models.KafkaError{}
+	case models.PingMethod:
+		var req models.PingRequest
+		return nil, decodePayload(cd, schema.Ping, kafkaReq.Payload, &req)
+	}
+
+	return nil, models.KafkaError{
+		Code:    errs.CodeInvalidMsg,
+		Message: "unknown method " + kafkaReq.Method,
+	}
+}
+
+// bookResult replies with the book when there is one. A failed update or
+// delete sends the current book along with the error
+func bookResult(book models.Book, errKafka models.KafkaError) (interface{}, models.KafkaError) {
+	if book.ID == 0 {
+		return nil, errKafka
+	}
+	return book, errKafka
+}
+
+func decodePayload(
+	cd codec.Codec,
+	name string,
+	payload []byte,
+	v interface{},
+) models.KafkaError {
+	if err := codec.Validate(cd, name, payload); err != nil {
+		return models.KafkaError{Code: errs.CodeInvalidMsg, Message: err.Error()}
+	}
+
+	if err := cd.Unmarshal(payload, v); err != nil {
+		return models.KafkaError{Code: errs.CodeInvalidMsg, Message: err.Error()}
+	}
+
+	return models.KafkaError{}
+}
+
+func groupByUser(books []models.Book) []models.UserBooksResponse {
+	usersMap := make(map[string]*models.UserBooksResponse)
+
+	for _, book := range books {
+		username := book.User.Username
+
+		if _, exists := usersMap[username]; !exists {
+			usersMap[username] = &models.UserBooksResponse{
+				Username:   username,
+				TotalBooks: 0,
+				Books:      []models.BookResponse{},
+			}
+		}
+
+		usersMap[username].Books = append(usersMap[username].Books, models.BookResponse{
+			ID:     book.ID,
+			Title:  book.Title,
+			Author: book.Author,
+			Price:  book.Price,
+		})
+		usersMap[username].TotalBooks++
+	}
+
+	result := make([]models.UserBooksResponse, 0, len(usersMap))
+	for _, userBooks := range usersMap {
+		result = append(result, *userBooks)
+	}
+
+	return result
+}
@@ -0,0 +1,166 @@
+package worker
+
+import (
+	"bookstore-api/api/repository"
+	"bookstore-api/internal/lib/codec"
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/sl"
+	"bookstore-api/internal/models"
+	cons "bookstore-api/internal/perskafka/consumer"
+	prod "bookstore-api/internal/perskafka/producer"
+	"bookstore-api/internal/tracing"
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+const (
+	replyRetryMin = 100 * time.Millisecond
+	replyRetryMax = 10 * time.Second
+	// replyRetryFor bounds the retries of one reply. The API stops waiting
+	// long before, and retrying longer would hold back the partition
+	replyRetryFor = time.Minute
+)
+
+// Worker consumes book requests, executes them against the database and
+// produces the replies. It is the only part of the system using BookRepository
+type Worker struct {
+	repo         repository.BookRepository
+	producer     *kafka.Producer
+	consumer     *kafka.Consumer
+	requestTopic string
+	replyTopic   string
+	workers      int
+	offsets      *offsetTracker
+	commitMu     sync.Mutex
+}
+
+func NewWorker(
+	r repository.BookRepository,
+	p *kafka.Producer,
+	c *kafka.Consumer,
+	requestTopic, replyTopic string,
+	workers int,
+) *Worker {
+	return &Worker{
+		repo:         r,
+		producer:     p,
+		consumer:     c,
+		requestTopic: requestTopic,
+		replyTopic:   replyTopic,
+		workers:      workers,
+		offsets:      newOffsetTracker(),
+	}
+}
+
+// Run reads requests until the consumer fails to subscribe
+func (w *Worker) Run() error {
+	if err := w.consumer.Subscribe(w.requestTopic, w.rebalance); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrKafkaConsumer, err)
+	}
+	defer w.consumer.Close()
+
+	pool := newOrderedPool(w.workers, w.workers)
+
+	for {
+		msg, err := cons.Read(w.consumer)
+		if err != nil {
+			log.Println("worker/worker.go |", err)
+		}
+		if msg == nil {
+			log.Println("Received nil message, skip")
+			continue
+		}
+
+		w.offsets.Track(msg.TopicPartition)
+
+		cd, env, err := codec.DecodeEnvelope(msg)
+		if err != nil {
+			log.Println("worker/worker.go | skip message", msg.TopicPartition, err)
+			w.commitOffset(msg)
+			continue
+		}
+
+		if env.Type != models.KafkaRequestType {
+			w.commitOffset(msg)
+			continue
+		}
+
+		key := msg.Key
+		if len(key) == 0 {
+			key = []byte(env.RelationID)
+		}
+
+		pool.Submit(key, func() {
+			// proccessRequest logs its own errors with the request ID. A
+			// reply it could not send is dropped, the request is applied
+			// and holding its offset back would stall the partition
+			w.proccessRequest(msg, cd, env)
+			w.commitOffset(msg)
+		})
+	}
+}
+
+// commitOffset marks msg as processed and commits its partition up to the
+// oldest message that is still being handled.
+func (w *Worker) commitOffset(msg *kafka.Message) {
+	w.commitMu.Lock()
+	defer w.commitMu.Unlock()
+
+	tp, ok := w.offsets.Done(msg.TopicPartition)
+	if !ok {
+		return
+	}
+
+	if _, err := w.consumer.CommitOffsets([]kafka.TopicPartition{tp}); err != nil {
+		log.Println("worker/worker.go | commit offset", tp, err)
+	}
+}
+
+func (w *Worker) rebalance(_ *kafka.Consumer, ev kafka.Event) error {
+	if e, ok := ev.(kafka.RevokedPartitions); ok {
+		w.offsets.Revoke(e.Partitions)
+	}
+	return nil
+}
+
+// reply keeps the key of the request, the user ID, so the API instances
+// know whose books a reply changed.
+//
+// The request is already applied when the reply fails, so only the reply is
+// retried, with backoff, for replyRetryFor and while the request is still
+// ours. Its offset can't be committed before, and holding it back blocks the
+// whole partition
+func (w *Worker) reply(ctx context.Context, request kafka.TopicPartition, key, res []byte, cd codec.Codec) error {
+	deadline := time.Now().Add(replyRetryFor)
+	delay := replyRetryMin
+	for {
+		msg := &kafka.Message{
+			TopicPartition: kafka.TopicPartition{
+				Topic:     &w.replyTopic,
+				Partition: kafka.PartitionAny,
+			},
+			Key:     key,
+			Value:   res,
+			Headers: codec.Headers(cd),
+		}
+		tracing.InjectKafka(ctx, msg)
+
+		err := prod.Send(w.producer, msg)
+		if err == nil || !w.offsets.Pending(request) {
+			return err
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("reply dropped after %v: %w", replyRetryFor, err)
+		}
+
+		slog.WarnContext(ctx, "worker.reply retry", "partition", request, "delay", delay, sl.Error(err))
+		time.Sleep(delay)
+		delay = min(2*delay, replyRetryMax)
+	}
+}
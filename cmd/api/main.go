@@ -0,0 +1,258 @@
+package main
+
+import (
+	hand "bookstore-api/api/handlers"
+	repo "bookstore-api/api/repository"
+	serv "bookstore-api/api/service"
+	_ "bookstore-api/docs"
+	"bookstore-api/internal/cache"
+	"bookstore-api/internal/config"
+	db "bookstore-api/internal/database"
+	"bookstore-api/internal/health"
+	"bookstore-api/internal/lib/codec"
+	"bookstore-api/internal/lib/i18n"
+	"bookstore-api/internal/metrics"
+	"bookstore-api/internal/middleware"
+	kadmin "bookstore-api/internal/perskafka/admin"
+	cons "bookstore-api/internal/perskafka/consumer"
+	prod "bookstore-api/internal/perskafka/producer"
+	"bookstore-api/internal/ratelimit"
+	"bookstore-api/internal/tracing"
+	"bookstore-api/internal/utils"
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// @title BookStore API
+// @version 1.1.3
+// @description RESP API for managing books and personal user list of books
+// @host localhost:8080
+// @BasePath /
+//
+// @securityDefinitions.basic BasicAuth
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+// @description JWT token with 'Bearer ' prefix. Example: `Bearer eyJhbGci...`
+func main() {
+	cfg := config.MustLoad(config.AppAPI, os.Args[1:])
+
+	gin.SetMode(gin.ReleaseMode)
+
+	utils.InitLogger(cfg.Log.Debug)
+
+	if err := i18n.SetFallback(cfg.Locale.Default); err != nil {
+		log.Fatal(err)
+	}
+
+	shutdownTracing, err := tracing.Init("bookstore-api", cfg.Tracing.Exporter)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// the API keeps the tables it needs on every request: users, idempotency
+	// keys, jobs and rate limit overrides. Books are only reached through the
+	// worker
+	db := db.InitDB(cfg.DB.DSN(), cfg.Log.Debug, cfg.DB.Migrate)
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := metrics.RegisterDBStats(sqlDB); err != nil {
+		log.Fatal(err)
+	}
+
+	// every API instance must see all replies, so each one gets its own group
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	kafkaConfig := cfg.Kafka.Client()
+
+	// fail before serving anything if the topics are not usable
+	if err := kadmin.EnsureTopics(kafkaConfig, cfg.Kafka.Topics.Specs(), cfg.Kafka.CreateTopics); err != nil {
+		log.Fatal(err)
+	}
+
+	producer, err := prod.NewProducer(kafkaConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	consumer, err := cons.NewConsumer(
+		kafkaConfig,
+		cfg.Kafka.GroupID+"-api-"+hostname,
+		"latest",
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	kafkaCodec, err := codec.ForContentType(cfg.Kafka.ContentType)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	bookServ := serv.NewBookService(
+		producer,
+		consumer,
+		cfg.Kafka.Topics.Request,
+		cfg.Kafka.Topics.Reply,
+		kafkaCodec,
+		repo.NewJobRepository(db),
+		cache.NewLRU(cfg.Cache.Size),
+		cfg.Cache.TTL,
+	)
+	bookHandler := hand.NewBookHandler(bookServ, cfg.Books.RequireIfMatch)
+
+	userRepo := repo.NewUserRepository(db)
+	userServ := serv.NewUserService(
+		userRepo,
+		[]byte(cfg.Auth.JWTSecret),
+		cfg.Auth.TokenTTL,
+		cache.NewLRU(cfg.Cache.Size),
+	)
+	userHandler := hand.NewUserHandler(userServ)
+
+	idempotencyRepo := repo.NewIdempotencyRepository(db)
+
+	// every instance keeps its own buckets, see ratelimit.Store
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter = ratelimit.NewLimiter(ratelimit.NewMemoryStore(time.Minute))
+	}
+	rateLimitServ := serv.NewRateLimitService(repo.NewRateLimitRepository(db), limiter)
+	if err := rateLimitServ.Reload(); err != nil {
+		log.Fatal(err)
+	}
+	if limiter != nil {
+		go rateLimitServ.Watch(cfg.RateLimit.RefreshInterval)
+	}
+	rateLimitHandler := hand.NewRateLimitHandler(rateLimitServ)
+
+	probes := health.New()
+	probes.Add("db", health.DB(sqlDB))
+	probes.Add("kafka", health.KafkaBrokers(producer))
+	probes.Add("kafka_consumer", health.KafkaSubscription(consumer, cfg.Kafka.Topics.Reply, true))
+	// a round trip costs a Kafka request per probe, keep the result a while
+	probes.Add("round_trip", health.Cached(15*time.Second, bookServ.Ping))
+
+	// probes and scrapes are neither traced nor logged
+	internalPaths := []string{"/metrics", "/healthz", "/readyz"}
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.HTTP.Proxies()); err != nil {
+		log.Fatal(err)
+	}
+	r.Use(middleware.Recovery())
+	r.Use(otelgin.Middleware("bookstore-api", otelgin.WithFilter(func(req *http.Request) bool {
+		return !slices.Contains(internalPaths, req.URL.Path)
+	})))
+	r.Use(
+		middleware.RequestID(),
+		middleware.AccessLog(internalPaths...),
+		middleware.Metrics(internalPaths...),
+		middleware.Locale(),
+		middleware.Errors(),
+	)
+	r.NoRoute(middleware.NoRoute)
+
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	r.GET("/healthz", gin.WrapH(probes.LiveHandler()))
+	r.GET("/readyz", gin.WrapH(probes.ReadyHandler()))
+
+	url := ginSwagger.URL("/swagger/doc.json")
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
+
+	// #######################___PUBLIC___######################
+	public := r.Group("/auth")
+	public.Use(middleware.RateLimit(limiter, "auth", cfg.RateLimit.Auth.Limit()))
+	{
+		public.POST("/register", userHandler.Register)
+		public.POST("/login", userHandler.Login)
+	}
+	// #########################################################
+	//
+	//
+	//
+	// #######################___PRIVATE___#####################
+	private := r.Group("/api")
+	private.Use(
+		middleware.JWTAuth([]byte(cfg.Auth.JWTSecret)),
+		middleware.RateLimit(limiter, "api", cfg.RateLimit.API.Limit()),
+		middleware.UserLocale(userServ),
+		middleware.Idempotency(idempotencyRepo, 24*time.Hour, 2*serv.ReplyTimeout),
+		middleware.RespondAsync(),
+		middleware.ChangeReason(),
+	)
+	{
+		private.GET("/books", bookHandler.GetUserBooks)
+		private.GET("/books/:id", bookHandler.GetBook)
+		private.GET("/books/:id/history", bookHandler.GetBookHistory)
+		private.POST("/books", bookHandler.PostBook)
+		private.POST("/books/batch", bookHandler.BatchBooks)
+		private.PATCH("/books/:id", bookHandler.UpdateBook)
+		private.DELETE("/books/:id", bookHandler.DeleteBook)
+		private.POST("/books/:id/revert", bookHandler.RevertBook)
+		private.GET("/jobs/:id", bookHandler.GetJob)
+		private.PUT("/me/locale", userHandler.SetLocale)
+	}
+	// #########################################################
+	//
+	//
+	//
+	// ########################___ADMIN___######################
+	admin := r.Group("/admin")
+	// limited before the password check, so guessing it is limited too
+	admin.Use(
+		middleware.RateLimit(limiter, "admin", cfg.RateLimit.Admin.Limit()),
+		middleware.AdminAuth(cfg.Auth.AdminUser, cfg.Auth.AdminPassword),
+	)
+	{
+		admin.GET("/books", bookHandler.GetAllBooks)
+		admin.GET("/users", userHandler.GetAllUsers)
+		admin.DELETE("/users/:username", userHandler.DeleteByUsername)
+		admin.GET("/rate-limits", rateLimitHandler.GetOverrides)
+		admin.PUT("/users/:username/rate-limit", rateLimitHandler.SetOverride)
+		admin.DELETE("/users/:username/rate-limit", rateLimitHandler.DeleteOverride)
+	}
+	// #########################################################
+
+	srv := &http.Server{Addr: cfg.HTTP.Addr, Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+	probes.Ready()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	// let load balancers see the failing /readyz before refusing requests
+	slog.Info("shutting down", "drain", cfg.HTTP.DrainTimeout)
+	probes.Drain()
+	time.Sleep(cfg.HTTP.DrainTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Println("main.go | shutdown:", err)
+	}
+}
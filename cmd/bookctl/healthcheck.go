@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// runHealthcheck probes a /readyz or /healthz URL and fails unless it
+// answers 200. The service images have no curl, compose uses this instead
+func runHealthcheck(args []string) error {
+	if len(args) != 1 {
+		return errors.New("healthcheck: want exactly one URL")
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(args[0])
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	io.Copy(os.Stdout, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("healthcheck: %s", resp.Status)
+	}
+	return nil
+}
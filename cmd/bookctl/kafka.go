@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bookstore-api/internal/config"
+	"bookstore-api/internal/lib/codec"
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/models"
+	kconf "bookstore-api/internal/perskafka/config"
+	cons "bookstore-api/internal/perskafka/consumer"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/google/uuid"
+)
+
+const metadataTimeoutMs = 10000
+
+// record is one line of the NDJSON written by tail and dump. Value keeps the
+// original bytes so the message can be republished exactly as it was sent
+type record struct {
+	Topic     string      `json:"topic"`
+	Partition int32       `json:"partition"`
+	Offset    int64       `json:"offset"`
+	Timestamp time.Time   `json:"timestamp"`
+	Key       string      `json:"key,omitempty"`
+	KeyBase64 []byte      `json:"key_base64,omitempty"`
+	Headers   []header    `json:"headers,omitempty"`
+	Message   interface{} `json:"message,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Value     []byte      `json:"value"`
+}
+
+// header holds a value that is not valid UTF-8 in ValueBase64, as text JSON
+// would replace its invalid bytes. The same goes for record.KeyBase64
+type header struct {
+	Key         string `json:"key"`
+	Value       string `json:"value,omitempty"`
+	ValueBase64 []byte `json:"value_base64,omitempty"`
+}
+
+func newHeader(h kafka.Header) header {
+	if utf8.Valid(h.Value) {
+		return header{Key: h.Key, Value: string(h.Value)}
+	}
+	return header{Key: h.Key, ValueBase64: h.Value}
+}
+
+func (h header) kafkaHeader() kafka.Header {
+	if h.ValueBase64 != nil {
+		return kafka.Header{Key: h.Key, Value: h.ValueBase64}
+	}
+	return kafka.Header{Key: h.Key, Value: []byte(h.Value)}
+}
+
+// kafkaOptions are the flags shared by the kafka subcommands, the
+// connection comes from the config flags
+type kafkaOptions struct {
+	topics     string
+	method     string
+	relationID string
+}
+
+func (o *kafkaOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.topics, "topics", "", "comma separated topics, the request and reply topics by default")
+	fs.StringVar(&o.method, "method", "", "only messages of this method")
+	fs.StringVar(&o.relationID, "relation", "", "only messages with this relation ID")
+}
+
+// topicList returns the topics given by -topics, or the request and reply
+// topics of the config
+func (o *kafkaOptions) topicList(t config.Topics) []string {
+	var topics []string
+	for _, topic := range strings.Split(o.topics, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	if len(topics) > 0 {
+		return topics
+	}
+
+	for _, topic := range []string{t.Request, t.Reply} {
+		if topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// match reports whether a message with env passes the filters. Messages
+// that could not be decoded only pass when no filter is set
+func (o *kafkaOptions) match(env *models.KafkaEnvelope) bool {
+	if o.method == "" && o.relationID == "" {
+		return true
+	}
+	if env == nil {
+		return false
+	}
+	if o.method != "" && env.Method != o.method {
+		return false
+	}
+	if o.relationID != "" && env.RelationID != o.relationID {
+		return false
+	}
+	return true
+}
+
+func runKafka(args []string) error {
+	if len(args) == 0 {
+		return errors.New("kafka: missing command, want tail, dump or republish")
+	}
+
+	switch args[0] {
+	case "tail":
+		return kafkaTail(args[1:])
+	case "dump":
+		return kafkaDump(args[1:])
+	case "republish":
+		return kafkaRepublish(args[1:])
+	}
+
+	return fmt.Errorf("kafka: unknown command %q", args[0])
+}
+
+// newReader returns a consumer with a throwaway group. Partitions are
+// assigned by hand and offsets are never committed, so reading does not
+// disturb the API or the worker
+func newReader(cfg kconf.Config) (*kafka.Consumer, error) {
+	c, err := cons.NewConsumer(cfg, "bookctl-"+uuid.NewString(), "latest")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrKafkaConsumer, err)
+	}
+	return c, nil
+}
+
+// assignFrom assigns every partition of topics starting at the first
+// message produced at or after from, or at the end when from is zero
+func assignFrom(c *kafka.Consumer, topics []string, from time.Time) ([]kafka.TopicPartition, error) {
+	var tps []kafka.TopicPartition
+	for _, topic := range topics {
+		md, err := c.GetMetadata(&topic, false, metadataTimeoutMs)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errs.ErrKafkaConsumer, err)
+		}
+
+		t, ok := md.Topics[topic]
+		if !ok || t.Error.Code() != kafka.ErrNoError {
+			return nil, fmt.Errorf("%w: topic %q: %v", errs.ErrKafkaConsumer, topic, t.Error)
+		}
+
+		for _, p := range t.Partitions {
+			tps = append(tps, kafka.TopicPartition{
+				Topic:     &topic,
+				Partition: p.ID,
+				Offset:    kafka.OffsetEnd,
+			})
+		}
+	}
+
+	if !from.IsZero() {
+		for i := range tps {
+			tps[i].Offset = kafka.Offset(from.UnixMilli())
+		}
+
+		var err error
+		tps, err = c.OffsetsForTimes(tps, metadataTimeoutMs)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errs.ErrKafkaConsumer, err)
+		}
+	}
+
+	if err := c.Assign(tps); err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrKafkaConsumer, err)
+	}
+
+	return tps, nil
+}
+
+// decodeRecord turns msg into a record with the envelope decoded. The
+// envelope is nil when the message is not a valid book request or reply
+func decodeRecord(msg *kafka.Message) (record, *models.KafkaEnvelope) {
+	rec := record{
+		Topic:     *msg.TopicPartition.Topic,
+		Partition: msg.TopicPartition.Partition,
+		Offset:    int64(msg.TopicPartition.Offset),
+		Timestamp: msg.Timestamp,
+		Value:     msg.Value,
+	}
+	if utf8.Valid(msg.Key) {
+		rec.Key = string(msg.Key)
+	} else {
+		rec.KeyBase64 = msg.Key
+	}
+	for _, h := range msg.Headers {
+		rec.Headers = append(rec.Headers, newHeader(h))
+	}
+
+	cd, env, err := codec.DecodeEnvelope(msg)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec, nil
+	}
+
+	rec.Message, err = decodeMessage(cd, env, msg.Value)
+	if err != nil {
+		rec.Error = err.Error()
+	}
+
+	return rec, &env
+}
+
+// decodeMessage reads the whole request or reply. Protobuf payloads are
+// converted to JSON so every record is readable
+func decodeMessage(cd codec.Codec, env models.KafkaEnvelope, value []byte) (interface{}, error) {
+	switch env.Type {
+	case models.KafkaRequestType:
+		var req models.KafkaBookRequest
+		err := cd.Unmarshal(value, &req)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errs.ErrInvalidMsg, err)
+		}
+
+		// the raw bytes stay in record.Value when the payload can't be decoded
+		req.Payload, err = codec.ToJSON(cd, models.NewRequestPayload(env.Method), req.Payload)
+		return req, err
+	case models.KafkaResponseType:
+		var res models.KafkaBookResponse
+		err := cd.Unmarshal(value, &res)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errs.ErrInvalidMsg, err)
+		}
+
+		res.Result, err = codec.ToJSON(cd, models.NewResult(env.Method), res.Result)
+		return res, err
+	}
+
+	return nil, fmt.Errorf("%w: unknown message type %q", errs.ErrInvalidMsg, env.Type)
+}
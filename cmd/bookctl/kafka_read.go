@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bookstore-api/internal/config"
+	"bookstore-api/internal/lib/errs"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+func kafkaTail(args []string) error {
+	var opts kafkaOptions
+	fs := flag.NewFlagSet("kafka tail", flag.ExitOnError)
+	opts.register(fs)
+	since := fs.Duration("since", 0, "start this far in the past instead of at the end")
+
+	cfg, err := config.Load(config.AppKafkaTool, fs, args)
+	if err != nil {
+		return err
+	}
+
+	c, err := newReader(cfg.Kafka.Client())
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var from time.Time
+	if *since > 0 {
+		from = time.Now().Add(-*since)
+	}
+	if _, err := assignFrom(c, opts.topicList(cfg.Kafka.Topics), from); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		switch ev := c.Poll(1000).(type) {
+		case *kafka.Message:
+			rec, env := decodeRecord(ev)
+			if !opts.match(env) {
+				continue
+			}
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		case kafka.Error:
+			if ev.IsFatal() {
+				return fmt.Errorf("%w: %v", errs.ErrKafkaConsumer, ev)
+			}
+			fmt.Fprintln(os.Stderr, "bookctl:", ev)
+		}
+	}
+}
+
+func kafkaDump(args []string) error {
+	var opts kafkaOptions
+	fs := flag.NewFlagSet("kafka dump", flag.ExitOnError)
+	opts.register(fs)
+	fromFlag := fs.String("from", "", "start of the range, RFC 3339 (required)")
+	toFlag := fs.String("to", "", "end of the range, RFC 3339, now by default")
+	out := fs.String("o", "-", "output file, - for stdout")
+
+	cfg, err := config.Load(config.AppKafkaTool, fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *fromFlag == "" {
+		return errors.New("kafka dump: -from is required")
+	}
+	from, err := time.Parse(time.RFC3339, *fromFlag)
+	if err != nil {
+		return fmt.Errorf("kafka dump: -from: %v", err)
+	}
+	to := time.Now()
+	if *toFlag != "" {
+		if to, err = time.Parse(time.RFC3339, *toFlag); err != nil {
+			return fmt.Errorf("kafka dump: -to: %v", err)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	c, err := newReader(cfg.Kafka.Client())
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	tps, err := assignFrom(c, opts.topicList(cfg.Kafka.Topics), from)
+	if err != nil {
+		return err
+	}
+
+	// read every partition up to the last message that existed when the
+	// dump started
+	remaining := make(map[string]int64)
+	for _, tp := range tps {
+		_, high, err := c.QueryWatermarkOffsets(*tp.Topic, tp.Partition, metadataTimeoutMs)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errs.ErrKafkaConsumer, err)
+		}
+		if tp.Offset >= 0 && int64(tp.Offset) < high {
+			remaining[partitionKey(tp)] = high - 1
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	written := 0
+	for len(remaining) > 0 {
+		switch ev := c.Poll(1000).(type) {
+		case *kafka.Message:
+			key := partitionKey(ev.TopicPartition)
+			last, ok := remaining[key]
+			if !ok {
+				continue
+			}
+			if int64(ev.TopicPartition.Offset) >= last {
+				delete(remaining, key)
+			}
+			if ev.Timestamp.After(to) {
+				delete(remaining, key)
+				continue
+			}
+
+			rec, env := decodeRecord(ev)
+			if !opts.match(env) {
+				continue
+			}
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+			written++
+		case kafka.Error:
+			if ev.IsFatal() {
+				return fmt.Errorf("%w: %v", errs.ErrKafkaConsumer, ev)
+			}
+			fmt.Fprintln(os.Stderr, "bookctl:", ev)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "dumped %d messages\n", written)
+	return nil
+}
+
+func partitionKey(tp kafka.TopicPartition) string {
+	return fmt.Sprintf("%s/%d", *tp.Topic, tp.Partition)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bookstore-api/internal/config"
+	"bookstore-api/internal/lib/codec"
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/models"
+	prod "bookstore-api/internal/perskafka/producer"
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// maxRecordSize bounds one NDJSON line, GetAllBooks replies can be large
+const maxRecordSize = 64 << 20
+
+func kafkaRepublish(args []string) error {
+	var opts kafkaOptions
+	fs := flag.NewFlagSet("kafka republish", flag.ExitOnError)
+	fs.StringVar(&opts.method, "method", "", "only messages of this method")
+	fs.StringVar(&opts.relationID, "relation", "", "only messages with this relation ID")
+	in := fs.String("in", "-", "NDJSON written by tail or dump, - for stdin")
+	topic := fs.String("topic", "", "produce to this topic instead of the original one")
+	dryRun := fs.Bool("dry-run", false, "print the selected messages without producing them")
+
+	cfg, err := config.Load(config.AppKafkaTool, fs, args)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var producer *kafka.Producer
+	if !*dryRun {
+		producer, err = prod.NewProducer(cfg.Kafka.Client())
+		if err != nil {
+			return fmt.Errorf("%w: %v", errs.ErrKafkaProducer, err)
+		}
+		defer producer.Close()
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	enc := json.NewEncoder(os.Stdout)
+	sent := 0
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+
+		msg := &kafka.Message{
+			TopicPartition: kafka.TopicPartition{
+				Topic:     &rec.Topic,
+				Partition: kafka.PartitionAny,
+			},
+			Value: rec.Value,
+		}
+		switch {
+		case rec.KeyBase64 != nil:
+			msg.Key = rec.KeyBase64
+		case rec.Key != "":
+			msg.Key = []byte(rec.Key)
+		}
+		for _, h := range rec.Headers {
+			msg.Headers = append(msg.Headers, h.kafkaHeader())
+		}
+		if *topic != "" {
+			msg.TopicPartition.Topic = topic
+		}
+
+		var env *models.KafkaEnvelope
+		if _, e, err := codec.DecodeEnvelope(msg); err == nil {
+			env = &e
+		}
+		if !opts.match(env) {
+			continue
+		}
+
+		if *dryRun {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := prod.Send(producer, msg); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		sent++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if !*dryRun {
+		fmt.Fprintf(os.Stderr, "republished %d messages\n", sent)
+	}
+	return nil
+}
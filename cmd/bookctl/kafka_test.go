@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+func TestHeaderRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      []byte
+		wantBase64 bool
+	}{
+		{name: "text", value: []byte("application/json")},
+		{name: "empty", value: []byte{}},
+		{name: "traceparent", value: []byte("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")},
+		{name: "binary", value: []byte{0x00, 0xff, 0xfe, 0x10}, wantBase64: true},
+		{name: "invalid utf-8", value: []byte("caf\xe9"), wantBase64: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHeader(kafka.Header{Key: "k", Value: tt.value})
+			if got := h.ValueBase64 != nil; got != tt.wantBase64 {
+				t.Errorf("base64 = %v, want %v", got, tt.wantBase64)
+			}
+
+			raw, err := json.Marshal(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded header
+			if err := json.Unmarshal(raw, &decoded); err != nil {
+				t.Fatal(err)
+			}
+
+			got := decoded.kafkaHeader()
+			if got.Key != "k" || !bytes.Equal(got.Value, tt.value) {
+				t.Errorf("round trip through %s = %q, want %q", raw, got.Value, tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `usage: bookctl <command> [arguments]
+
+commands:
+  kafka tail       print book requests and replies as they arrive
+  kafka dump       write messages of a time range as NDJSON
+  kafka republish  produce messages from an NDJSON dump again
+  migrate up       apply pending database migrations
+  migrate down     revert the last migrations, -steps sets how many
+  migrate status   list migrations and whether they are applied
+  healthcheck URL  exit 0 when URL answers 200, for container healthchecks
+
+run "bookctl kafka <command> -h" or "bookctl migrate <command> -h" for the
+flags of a command
+`
+
+// bookctl is the operator tool for the bookstore services. Kafka and database
+// settings come from the same environment as the API and the worker
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "kafka":
+		err = runKafka(os.Args[2:])
+	case "migrate":
+		err = runMigrate(os.Args[2:])
+	case "healthcheck":
+		err = runHealthcheck(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bookctl:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bookstore-api/internal/config"
+	"bookstore-api/internal/database"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// runMigrate applies, reverts or lists the schema migrations. The database
+// is configured like the services: config file, DB_* variables or -db.* flags
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate: missing command, want up, down or status")
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ExitOnError)
+	var steps *int
+	switch args[0] {
+	case "up", "status":
+	case "down":
+		steps = fs.Int("steps", 1, "number of migrations to revert")
+	default:
+		return fmt.Errorf("migrate: unknown command %q", args[0])
+	}
+
+	cfg, err := config.Load(config.AppMigrate, fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	db, err := database.Open(cfg.DB.DSN(), cfg.Log.Debug)
+	if err != nil {
+		return err
+	}
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		if *steps < 1 {
+			return errors.New("migrate down: -steps must be positive")
+		}
+		return migrator.Down(ctx, *steps)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, st := range statuses {
+		appliedAt := "-"
+		if st.AppliedAt != nil {
+			appliedAt = st.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", st.Version, st.Name, st.State, appliedAt)
+	}
+	return w.Flush()
+}
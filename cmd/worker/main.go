@@ -0,0 +1,114 @@
+package main
+
+import (
+	repo "bookstore-api/api/repository"
+	serv "bookstore-api/api/service"
+	"bookstore-api/api/worker"
+	"bookstore-api/internal/config"
+	db "bookstore-api/internal/database"
+	"bookstore-api/internal/health"
+	"bookstore-api/internal/metrics"
+	kadmin "bookstore-api/internal/perskafka/admin"
+	cons "bookstore-api/internal/perskafka/consumer"
+	prod "bookstore-api/internal/perskafka/producer"
+	"bookstore-api/internal/tracing"
+	"bookstore-api/internal/utils"
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Worker executes book requests from Kafka against PostgreSQL and publishes
+// domain events from the outbox. It can be scaled apart from the HTTP API
+func main() {
+	cfg := config.MustLoad(config.AppWorker, os.Args[1:])
+
+	utils.InitLogger(cfg.Log.Debug)
+
+	shutdownTracing, err := tracing.Init("bookstore-worker", cfg.Tracing.Exporter)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	db := db.InitDB(cfg.DB.DSN(), cfg.Log.Debug, cfg.DB.Migrate)
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := metrics.RegisterDBStats(sqlDB); err != nil {
+		log.Fatal(err)
+	}
+
+	kafkaConfig := cfg.Kafka.Client()
+
+	// fail before serving anything if the topics are not usable
+	if err := kadmin.EnsureTopics(kafkaConfig, cfg.Kafka.Topics.Specs(), cfg.Kafka.CreateTopics); err != nil {
+		log.Fatal(err)
+	}
+
+	producer, err := prod.NewProducer(kafkaConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	consumer, err := cons.NewConsumer(kafkaConfig, cfg.Kafka.GroupID, "earliest")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	outboxRepo := repo.NewOutboxRepository(db)
+	serv.StartOutboxRelay(outboxRepo, producer, cfg.Kafka.Topics.Events)
+
+	bookRepo := repo.NewBookRepository(db)
+	w := worker.NewWorker(
+		bookRepo,
+		producer,
+		consumer,
+		cfg.Kafka.Topics.Request,
+		cfg.Kafka.Topics.Reply,
+		cfg.Worker.Concurrency,
+	)
+
+	go metrics.WatchConsumerLag(consumer, 15*time.Second)
+	go metrics.WatchTotals(sqlDB, time.Minute)
+
+	// workers beyond the partition count legitimately own no partitions,
+	// so only the subscription is required
+	probes := health.New()
+	probes.Add("db", health.DB(sqlDB))
+	probes.Add("kafka", health.KafkaBrokers(producer))
+	probes.Add("kafka_consumer", health.KafkaSubscription(consumer, cfg.Kafka.Topics.Request, false))
+
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		mux.Handle("/healthz", probes.LiveHandler())
+		mux.Handle("/readyz", probes.ReadyHandler())
+		log.Fatal(http.ListenAndServe(cfg.Metrics.Addr, mux))
+	}()
+
+	// unfinished requests are not committed, Kafka redelivers them to the
+	// next worker
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+		<-stop
+
+		slog.Info("shutting down", "drain", cfg.HTTP.DrainTimeout)
+		probes.Drain()
+		time.Sleep(cfg.HTTP.DrainTimeout)
+
+		shutdownTracing(context.Background())
+		os.Exit(0)
+	}()
+
+	probes.Ready()
+	log.Fatal(w.Run())
+}
@@ -44,19 +44,69 @@ const docTemplate = `{
                     "401": {
                         "description": "User unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "404": {
                         "description": "Records not found",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Database or Server error",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/rate-limits": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Get the rate limits set for single users",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get rate limit overrides",
+                "operationId": "get-rate-limits",
+                "responses": {
+                    "200": {
+                        "description": "Rate limits of users",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.RateLimitsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Database or Server error",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     }
                 }
@@ -91,19 +141,25 @@ const docTemplate = `{
                     "401": {
                         "description": "User unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "404": {
                         "description": "Records not found",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Database or Server error",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     }
                 }
@@ -147,19 +203,159 @@ const docTemplate = `{
                     "401": {
                         "description": "User unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "404": {
                         "description": "Record not found",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Database or Server error",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{username}/rate-limit": {
+            "put": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Replace the rate limit of the /api routes for the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Set rate limit of a user",
+                "operationId": "set-rate-limit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Token bucket of the user",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.RateLimitRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rate limit saved",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid body request or period",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "User unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Database or Server error",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Return the user to the limit of the /api routes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Remove rate limit of a user",
+                "operationId": "delete-rate-limit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rate limit removed",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "User or rate limit not found",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Database or Server error",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     }
                 }
@@ -206,6 +402,12 @@ const docTemplate = `{
                         "description": "Limit number of records",
                         "name": "limit",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a list the client has, answered with 304 while it is current",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -215,28 +417,37 @@ const docTemplate = `{
                             "$ref": "#/definitions/bookstore-api_internal_models.GetBooks"
                         }
                     },
+                    "304": {
+                        "description": "List unchanged since the ETag in If-None-Match"
+                    },
                     "400": {
                         "description": "Invalid query body",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "401": {
                         "description": "User unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "404": {
                         "description": "Records not found",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Database or Server error",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     }
                 }
@@ -266,46 +477,734 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.BookRequest"
+                            "$ref": "#/definitions/bookstore-api_internal_models.BookRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "maxLength": 255,
+                        "type": "string",
+                        "description": "Why the books are changed, saved in their history",
+                        "name": "X-Change-Reason",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "respond-async to get a job to poll instead of waiting for the result",
+                        "name": "Prefer",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Message about successfully creating",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.SuccessResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Request accepted, poll GET /api/jobs/{id}",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "User unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key in progress",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused with another body",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Database or Server error",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/books/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create, update and delete books in one transaction. In atomic mode (default) one failed operation rolls back the whole batch, in per_item mode the successful operations are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Batch book operations",
+                "operationId": "batch-user-books",
+                "parameters": [
+                    {
+                        "description": "Operations to apply, at most 1000",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.BatchRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "maxLength": 255,
+                        "type": "string",
+                        "description": "Why the books are changed, saved in their history",
+                        "name": "X-Change-Reason",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "respond-async to get a job to poll instead of waiting for the result",
+                        "name": "Prefer",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Every operation succeeded",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.BatchResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Request accepted, poll GET /api/jobs/{id}",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Job"
+                        }
+                    },
+                    "207": {
+                        "description": "Some operations failed, see the status of each one",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "User unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key in progress",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused with another body",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Database or Server error",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/books/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a book of user with its version in ETag, send it in If-Match to update or delete the book",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Get book",
+                "operationId": "get-user-book",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 13,
+                        "description": "ID of the book",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the book the client has, answered with 304 while it is current",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Book, ETag header holds its version",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Book"
+                        }
+                    },
+                    "304": {
+                        "description": "Book unchanged since the ETag in If-None-Match"
+                    },
+                    "400": {
+                        "description": "Invalid book ID",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "User unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Record not found",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Database or Server error",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Permanently delete book",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Delete book",
+                "operationId": "delete-user-book",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 3,
+                        "description": "ID of the book to delete",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the book from GET /api/books/{id}, the delete fails with 412 if the book changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "maxLength": 255,
+                        "type": "string",
+                        "description": "Why the books are changed, saved in their history",
+                        "name": "X-Change-Reason",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "respond-async to get a job to poll instead of waiting for the result",
+                        "name": "Prefer",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Message about successfully deleting",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.SuccessResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Request accepted, poll GET /api/jobs/{id}",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "User unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Record not found",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key in progress",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Book changed since If-Match, current holds the stored book",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused with another body",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is required",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Database or Server error",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Change book to new parameters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Update book",
+                "operationId": "update-user-book",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 13,
+                        "description": "ID of the book to change",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New data for change existing data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.BookRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the book from GET /api/books/{id}, the update fails with 412 if the book changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "maxLength": 255,
+                        "type": "string",
+                        "description": "Why the books are changed, saved in their history",
+                        "name": "X-Change-Reason",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "respond-async to get a job to poll instead of waiting for the result",
+                        "name": "Prefer",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Message about successfully updating, ETag header holds the new version",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.SuccessResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Request accepted, poll GET /api/jobs/{id}",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "User unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Record not found",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key in progress",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Book changed since If-Match, current holds the stored book",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused with another body",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match is required",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Database or Server error",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/books/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the revisions of a book oldest first: the book after every change, or before it was deleted, who made the change, why, and which fields it changed. The history is kept after the book is deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Get book history",
+                "operationId": "get-user-book-history",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 13,
+                        "description": "ID of the book",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Revisions of the book",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.BookHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid book ID",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "User unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Book has no history",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Database or Server error",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/books/{id}/revert": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Set title, author and price of the book back to a revision from its history. The revert is an ordinary update: it makes a new revision and checks If-Match. A deleted book is recreated with its old ID from the revision, If-Match then holds the version it was deleted at",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Revert book",
+                "operationId": "revert-user-book",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 13,
+                        "description": "ID of the book to revert",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 7,
+                        "description": "Revision to restore, see GET /api/books/{id}/history",
+                        "name": "revision",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the book from GET /api/books/{id}, the revert fails with 412 if the book changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "maxLength": 255,
+                        "type": "string",
+                        "description": "Why the book is reverted, by default the revision it is reverted to",
+                        "name": "X-Change-Reason",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "respond-async to get a job to poll instead of waiting for the result",
+                        "name": "Prefer",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Message about successfully reverting, ETag header holds the new version",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.SuccessResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Request accepted, poll GET /api/jobs/{id}",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid book ID, revision or If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "User unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Book or revision not found",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key in progress",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Book changed since If-Match, current holds the stored book",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Message about successfully creating",
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused with another body",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.SuccessResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
-                    "400": {
-                        "description": "Invalid request body",
+                    "428": {
+                        "description": "If-Match is required",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
-                    "401": {
-                        "description": "User unauthorized",
+                    "429": {
+                        "description": "Too many requests",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Database or Server error",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/books/{id}": {
-            "delete": {
+        "/api/jobs/{id}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Permanently delete book",
+                "description": "Status of a write request sent with \"Prefer: respond-async\". Jobs are kept for 24 hours",
                 "consumes": [
                     "application/json"
                 ],
@@ -315,14 +1214,13 @@ const docTemplate = `{
                 "tags": [
                     "Books"
                 ],
-                "summary": "Delete book",
-                "operationId": "delete-user-book",
+                "summary": "Get job",
+                "operationId": "get-job",
                 "parameters": [
                     {
-                        "minimum": 1,
-                        "type": "integer",
-                        "example": 3,
-                        "description": "ID of the book to delete",
+                        "type": "string",
+                        "example": "5f0c7a4e-8a8e-4d4b-9d43-1d2b2f1c9e77",
+                        "description": "Job ID returned with 202 Accepted",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -330,44 +1228,52 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Message about successfully deleting",
+                        "description": "Job status, result or error code",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.SuccessResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Job"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body",
+                        "description": "Invalid job ID",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "401": {
                         "description": "User unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "404": {
-                        "description": "Record not found",
+                        "description": "Job not found",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Database or Server error",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/api/me/locale": {
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Change book to new parameters",
+                "description": "Save the language of messages and errors for the user. It overrides Accept-Language, an empty locale removes the override",
                 "consumes": [
                     "application/json"
                 ],
@@ -375,59 +1281,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Books"
+                    "Authorization"
                 ],
-                "summary": "Update book",
-                "operationId": "update-user-book",
+                "summary": "Set language",
+                "operationId": "set-user-locale",
                 "parameters": [
                     {
-                        "minimum": 1,
-                        "type": "integer",
-                        "example": 13,
-                        "description": "ID of the book to change",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "New data for change existing data",
+                        "description": "Locale: ru, en or empty",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.BookRequest"
+                            "$ref": "#/definitions/bookstore-api_internal_models.LocaleRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Message about successfully updating",
+                        "description": "Locale saved",
                         "schema": {
                             "$ref": "#/definitions/bookstore-api_internal_models.SuccessResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body",
+                        "description": "Unsupported locale",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "401": {
                         "description": "User unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Record not found",
+                    "429": {
+                        "description": "Too many requests",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Database or Server error",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     }
                 }
@@ -468,25 +1365,31 @@ const docTemplate = `{
                     "400": {
                         "description": "Invalid body request",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "401": {
                         "description": "User do not registred",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "403": {
                         "description": "Incorrect password",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     }
                 }
@@ -527,19 +1430,25 @@ const docTemplate = `{
                     "400": {
                         "description": "Invalid body request",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "401": {
                         "description": "User unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/bookstore-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/bookstore-api_internal_models.Problem"
                         }
                     }
                 }
@@ -547,8 +1456,168 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "bookstore-api_internal_lib_errs.Code": {
+            "type": "string",
+            "enum": [
+                "INVALID_PARAM",
+                "INVALID_ID",
+                "INVALID_BODY",
+                "NOT_FOUND",
+                "DB_OPERATION",
+                "INTERNAL",
+                "NOT_REGISTERED",
+                "NOT_AUTHORIZED",
+                "INVALID_TOKEN",
+                "TIMEOUT",
+                "KAFKA_PRODUCER",
+                "KAFKA_CONSUMER",
+                "INVALID_MESSAGE",
+                "KAFKA_ADMIN",
+                "ROLLED_BACK",
+                "MIGRATION",
+                "IDEMPOTENCY_KEY_IN_USE",
+                "IDEMPOTENCY_KEY_REUSED",
+                "ROUTE_NOT_FOUND",
+                "RATE_LIMITED",
+                "PRECONDITION_FAILED",
+                "PRECONDITION_REQUIRED"
+            ],
+            "x-enum-varnames": [
+                "CodeInvalidParam",
+                "CodeInvalidID",
+                "CodeInvalidBody",
+                "CodeNotFound",
+                "CodeDBOperation",
+                "CodeInternal",
+                "CodeNotRegistred",
+                "CodeNotAuthorized",
+                "CodeInvalidToken",
+                "CodeTimeout",
+                "CodeKafkaProducer",
+                "CodeKafkaConsumer",
+                "CodeInvalidMsg",
+                "CodeKafkaAdmin",
+                "CodeRolledBack",
+                "CodeMigration",
+                "CodeKeyInUse",
+                "CodeKeyReused",
+                "CodeRouteNotFound",
+                "CodeRateLimited",
+                "CodePreconditionFailed",
+                "CodePreconditionRequired"
+            ]
+        },
+        "bookstore-api_internal_lib_errs.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "title"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "is required"
+                }
+            }
+        },
+        "bookstore-api_internal_models.BatchOperation": {
+            "description": "One operation of a batch. create needs book, update needs id and book, delete needs id",
+            "type": "object",
+            "required": [
+                "op"
+            ],
+            "properties": {
+                "book": {
+                    "$ref": "#/definitions/bookstore-api_internal_models.BookRequest"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ],
+                    "example": "update"
+                },
+                "version": {
+                    "description": "Version makes update and delete fail with PRECONDITION_FAILED when\nthe book changed since, 0 skips the check",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "bookstore-api_internal_models.BatchRequest": {
+            "description": "Several book operations executed in one transaction",
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "atomic",
+                        "per_item"
+                    ],
+                    "example": "atomic"
+                },
+                "operations": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/bookstore-api_internal_models.BatchOperation"
+                    }
+                }
+            }
+        },
+        "bookstore-api_internal_models.BatchResponse": {
+            "description": "Batch results in request order. committed is false when an atomic batch was rolled back",
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/bookstore-api_internal_models.BatchResult"
+                    }
+                }
+            }
+        },
+        "bookstore-api_internal_models.BatchResult": {
+            "description": "Status of one batch operation, error holds a stable error code",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "NOT_FOUND"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 15
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "op": {
+                    "type": "string",
+                    "example": "create"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 201
+                }
+            }
+        },
         "bookstore-api_internal_models.Book": {
-            "description": "Book model to show it contains",
+            "description": "Book model to show it contains. Version grows with every change, send it back in If-Match to update or delete only the version you have seen",
             "type": "object",
             "required": [
                 "author",
@@ -560,6 +1629,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Л. Н. Толстой"
                 },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-02T15:04:05Z"
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
@@ -572,9 +1645,33 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Война и мир"
                 },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-01-03T10:00:00Z"
+                },
                 "user_id": {
                     "type": "integer",
                     "example": 1
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "bookstore-api_internal_models.BookHistoryResponse": {
+            "description": "History of a book, oldest revision first",
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/bookstore-api_internal_models.RevisionResponse"
+                    }
                 }
             }
         },
@@ -623,13 +1720,19 @@ const docTemplate = `{
                 }
             }
         },
-        "bookstore-api_internal_models.ErrorResponse": {
-            "description": "Default error response",
+        "bookstore-api_internal_models.FieldChange": {
+            "description": "Field of the book changed by a revision, from is null for a created book",
             "type": "object",
             "properties": {
-                "error": {
+                "field": {
                     "type": "string",
-                    "example": "error description"
+                    "example": "price"
+                },
+                "from": {
+                    "type": "object"
+                },
+                "to": {
+                    "type": "object"
                 }
             }
         },
@@ -648,6 +1751,51 @@ const docTemplate = `{
                 }
             }
         },
+        "bookstore-api_internal_models.Job": {
+            "description": "Write request executed asynchronously. result holds the worker reply, error a stable error code",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/bookstore-api_internal_lib_errs.Code"
+                        }
+                    ],
+                    "example": "NOT_FOUND"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f0c7a4e-8a8e-4d4b-9d43-1d2b2f1c9e77"
+                },
+                "method": {
+                    "type": "string",
+                    "example": "PostBookMethod"
+                },
+                "result": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "succeeded"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "bookstore-api_internal_models.LocaleRequest": {
+            "description": "Preferred language of the user, empty to follow Accept-Language",
+            "type": "object",
+            "properties": {
+                "locale": {
+                    "type": "string",
+                    "example": "en"
+                }
+            }
+        },
         "bookstore-api_internal_models.MetaBook": {
             "description": "Books response metadata",
             "type": "object",
@@ -662,6 +1810,112 @@ const docTemplate = `{
                 }
             }
         },
+        "bookstore-api_internal_models.Problem": {
+            "description": "Error response (RFC 7807). code is a stable identifier from the error catalogue, request_id matches the X-Request-ID header",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/bookstore-api_internal_lib_errs.Code"
+                        }
+                    ],
+                    "example": "NOT_FOUND"
+                },
+                "current": {
+                    "description": "Current is the resource as it is now, sent with PRECONDITION_FAILED",
+                    "type": "object"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "record not found"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/bookstore-api_internal_lib_errs.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/books/13"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "5f0c7a4e-8a8e-4d4b-9d43-1d2b2f1c9e77"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:bookstore:error:NOT_FOUND"
+                }
+            }
+        },
+        "bookstore-api_internal_models.RateLimitRequest": {
+            "description": "Token bucket of a user: burst requests at once, refilled at requests per period. A zero burst means requests",
+            "type": "object",
+            "required": [
+                "period",
+                "requests"
+            ],
+            "properties": {
+                "burst": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 50
+                },
+                "period": {
+                    "type": "string",
+                    "example": "1m"
+                },
+                "requests": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 600
+                }
+            }
+        },
+        "bookstore-api_internal_models.RateLimitResponse": {
+            "description": "Rate limit set for a user",
+            "type": "object",
+            "properties": {
+                "burst": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "period": {
+                    "type": "string",
+                    "example": "1m0s"
+                },
+                "requests": {
+                    "type": "integer",
+                    "example": 600
+                },
+                "username": {
+                    "type": "string",
+                    "example": "Wladim1r"
+                }
+            }
+        },
+        "bookstore-api_internal_models.RateLimitsResponse": {
+            "description": "Rate limits set for single users",
+            "type": "object",
+            "properties": {
+                "overrides": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/bookstore-api_internal_models.RateLimitResponse"
+                    }
+                }
+            }
+        },
         "bookstore-api_internal_models.Request": {
             "description": "User credentials for login or registration",
             "type": "object",
@@ -680,6 +1934,60 @@ const docTemplate = `{
                 }
             }
         },
+        "bookstore-api_internal_models.RevisionResponse": {
+            "description": "Revision of a book: the book as it was after the change, or before the deletion, and the fields the change touched",
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string",
+                    "example": "user:1"
+                },
+                "author": {
+                    "type": "string",
+                    "example": "Л. Н. Толстой"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/bookstore-api_internal_models.FieldChange"
+                    }
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-03T10:00:00Z"
+                },
+                "operation": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete",
+                        "restore"
+                    ],
+                    "example": "update"
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 1100
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "discount"
+                },
+                "revision": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Война и мир"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
         "bookstore-api_internal_models.SuccessResponse": {
             "description": "Default successfully response",
             "type": "object",
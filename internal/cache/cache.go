@@ -0,0 +1,15 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores encoded values by key. The LRU keeps them in this process, a
+// cache shared by the API instances (e.g. Redis) implements the same
+// interface. Errors are reported so callers can treat them as misses
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value for ttl, a zero ttl keeps it until it is evicted
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRUEviction(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		ops  []string // "set:k" or "get:k"
+		kept []string
+		gone []string
+	}{
+		{
+			name: "oldest is evicted",
+			size: 2,
+			ops:  []string{"set:a", "set:b", "set:c"},
+			kept: []string{"b", "c"},
+			gone: []string{"a"},
+		},
+		{
+			name: "read keeps a value",
+			size: 2,
+			ops:  []string{"set:a", "set:b", "get:a", "set:c"},
+			kept: []string{"a", "c"},
+			gone: []string{"b"},
+		},
+		{
+			name: "overwrite keeps a value",
+			size: 2,
+			ops:  []string{"set:a", "set:b", "set:a", "set:c"},
+			kept: []string{"a", "c"},
+			gone: []string{"b"},
+		},
+		{
+			name: "size one",
+			size: 1,
+			ops:  []string{"set:a", "set:b"},
+			kept: []string{"b"},
+			gone: []string{"a"},
+		},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewLRU(tt.size)
+			for _, op := range tt.ops {
+				key := op[4:]
+				switch op[:3] {
+				case "set":
+					if err := c.Set(ctx, key, []byte(key), 0); err != nil {
+						t.Fatal(err)
+					}
+				case "get":
+					c.Get(ctx, key)
+				}
+			}
+
+			for _, key := range tt.kept {
+				if v, ok, _ := c.Get(ctx, key); !ok || string(v) != key {
+					t.Errorf("%s = %q, %v, want it kept", key, v, ok)
+				}
+			}
+			for _, key := range tt.gone {
+				if _, ok, _ := c.Get(ctx, key); ok {
+					t.Errorf("%s is still cached", key)
+				}
+			}
+		})
+	}
+}
+
+func TestLRUTTL(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+
+	c.Set(ctx, "short", []byte("1"), 20*time.Millisecond)
+	c.Set(ctx, "long", []byte("2"), time.Hour)
+	c.Set(ctx, "forever", []byte("3"), 0)
+
+	if _, ok, _ := c.Get(ctx, "short"); !ok {
+		t.Fatal("value expired before its ttl")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"short", false},
+		{"long", true},
+		{"forever", true},
+	}
+	for _, tt := range tests {
+		if _, ok, _ := c.Get(ctx, tt.key); ok != tt.want {
+			t.Errorf("%s cached = %v, want %v", tt.key, ok, tt.want)
+		}
+	}
+
+	// an expired value frees its slot
+	if n := c.(*lru).order.Len(); n != 2 {
+		t.Errorf("%d values left, want 2", n)
+	}
+}
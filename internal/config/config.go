@@ -0,0 +1,298 @@
+package config
+
+import (
+	"bookstore-api/internal/models"
+	kadmin "bookstore-api/internal/perskafka/admin"
+	kconf "bookstore-api/internal/perskafka/config"
+	"bookstore-api/internal/ratelimit"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Config is the settings of the API and the worker. Every value is taken
+// from, in increasing precedence: the defaults, the YAML file passed with
+// -config (or CONFIG_FILE), the environment variable named in the env tag
+// and the flag named after the YAML path, e.g. -db.host
+type Config struct {
+	HTTP      HTTP      `yaml:"http"`
+	Metrics   Metrics   `yaml:"metrics"`
+	Log       Log       `yaml:"log"`
+	Tracing   Tracing   `yaml:"tracing"`
+	DB        DB        `yaml:"db"`
+	Kafka     Kafka     `yaml:"kafka"`
+	Worker    Worker    `yaml:"worker"`
+	Auth      Auth      `yaml:"auth"`
+	Locale    Locale    `yaml:"locale"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Cache     Cache     `yaml:"cache"`
+	Books     Books     `yaml:"books"`
+}
+
+type HTTP struct {
+	Addr string `yaml:"addr" env:"HTTP_ADDR"`
+
+	// DrainTimeout is how long /readyz fails before the server stops
+	// accepting requests, ShutdownTimeout bounds the wait for running ones
+	DrainTimeout    time.Duration `yaml:"drain_timeout"    env:"HTTP_DRAIN_TIMEOUT"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
+
+	// TrustedProxies are the comma separated addresses or CIDRs allowed to
+	// set X-Forwarded-For. Without them the client IP is the peer address
+	TrustedProxies string `yaml:"trusted_proxies" env:"HTTP_TRUSTED_PROXIES"`
+}
+
+// Proxies returns TrustedProxies as a list
+func (h HTTP) Proxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(h.TrustedProxies, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+type Metrics struct {
+	// Addr is the address of the worker metrics server, the API serves
+	// /metrics on its HTTP address
+	Addr string `yaml:"addr" env:"METRICS_ADDR"`
+}
+
+type Log struct {
+	Debug bool `yaml:"debug" env:"DEBUG_MODE"`
+}
+
+type Tracing struct {
+	// Exporter is otlp, stdout or none. The OTLP exporter reads the
+	// standard OTEL_EXPORTER_OTLP_* variables
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER"`
+}
+
+type DB struct {
+	Host         string `yaml:"host"          env:"DB_HOST"`
+	Port         int    `yaml:"port"          env:"DB_PORT"`
+	User         string `yaml:"user"          env:"DB_USER"`
+	Password     string `yaml:"password"      env:"DB_PASSWORD" secret:"true"`
+	PasswordFile string `yaml:"password_file" env:"DB_PASSWORD_FILE"`
+	Name         string `yaml:"name"          env:"DB_NAME"`
+	SSLMode      string `yaml:"sslmode"       env:"DB_SSLMODE"`
+
+	// Migrate applies pending migrations on startup. When false the
+	// service only checks that the schema is up to date
+	Migrate bool `yaml:"migrate" env:"DB_MIGRATE"`
+}
+
+// DSN returns the connection string for the postgres driver. Values are
+// quoted, so empty ones and ones with spaces survive
+func (d DB) DSN() string {
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return fmt.Sprintf("host='%s' user='%s' password='%s' dbname='%s' port=%d sslmode='%s'",
+		quote.Replace(d.Host),
+		quote.Replace(d.User),
+		quote.Replace(d.Password),
+		quote.Replace(d.Name),
+		d.Port,
+		quote.Replace(d.SSLMode),
+	)
+}
+
+type Kafka struct {
+	Brokers      string `yaml:"brokers"       env:"BOOTTRAP"`
+	GroupID      string `yaml:"group_id"      env:"GROUP_ID"`
+	ContentType  string `yaml:"content_type"  env:"KAFKA_CONTENT_TYPE"`
+	CreateTopics bool   `yaml:"create_topics" env:"KAFKA_CREATE_TOPICS"`
+
+	SecurityProtocol   string `yaml:"security_protocol"     env:"KAFKA_SECURITY_PROTOCOL"`
+	SASLMechanism      string `yaml:"sasl_mechanism"        env:"KAFKA_SASL_MECHANISM"`
+	SASLUsernameFile   string `yaml:"sasl_username_file"    env:"KAFKA_SASL_USERNAME_FILE"`
+	SASLPasswordFile   string `yaml:"sasl_password_file"    env:"KAFKA_SASL_PASSWORD_FILE"`
+	SSLCALocation      string `yaml:"ssl_ca_location"       env:"KAFKA_SSL_CA_LOCATION"`
+	SSLCertLocation    string `yaml:"ssl_cert_location"     env:"KAFKA_SSL_CERT_LOCATION"`
+	SSLKeyLocation     string `yaml:"ssl_key_location"      env:"KAFKA_SSL_KEY_LOCATION"`
+	SSLKeyPasswordFile string `yaml:"ssl_key_password_file" env:"KAFKA_SSL_KEY_PASSWORD_FILE"`
+
+	// Overrides are raw librdkafka properties, in the environment written
+	// as "key=value,key=value"
+	Overrides map[string]string `yaml:"overrides" env:"KAFKA_OVERRIDES"`
+
+	Topics Topics `yaml:"topics"`
+}
+
+// Client returns the connection settings for producers and consumers
+func (k Kafka) Client() kconf.Config {
+	return kconf.Config{
+		Brokers:            k.Brokers,
+		SecurityProtocol:   k.SecurityProtocol,
+		SASLMechanism:      k.SASLMechanism,
+		SASLUsernameFile:   k.SASLUsernameFile,
+		SASLPasswordFile:   k.SASLPasswordFile,
+		SSLCALocation:      k.SSLCALocation,
+		SSLCertLocation:    k.SSLCertLocation,
+		SSLKeyLocation:     k.SSLKeyLocation,
+		SSLKeyPasswordFile: k.SSLKeyPasswordFile,
+		Overrides:          k.Overrides,
+	}
+}
+
+// Topics names the topics of the services. DLQ is optional, Events is only
+// required by the worker.
+// Per topic settings left at zero are taken from Defaults
+type Topics struct {
+	Request string `yaml:"request" env:"TOPIC"`
+	Reply   string `yaml:"reply"   env:"REPLY_TOPIC"`
+	DLQ     string `yaml:"dlq"     env:"DLQ_TOPIC"`
+	Events  string `yaml:"events"  env:"EVENTS_TOPIC"`
+
+	Defaults        TopicSettings `yaml:"defaults"         env:"KAFKA_TOPIC_"`
+	RequestSettings TopicSettings `yaml:"request_settings" env:"KAFKA_REQUEST_"`
+	ReplySettings   TopicSettings `yaml:"reply_settings"   env:"KAFKA_REPLY_"`
+	DLQSettings     TopicSettings `yaml:"dlq_settings"     env:"KAFKA_DLQ_"`
+	EventsSettings  TopicSettings `yaml:"events_settings"  env:"KAFKA_EVENTS_"`
+}
+
+type TopicSettings struct {
+	Partitions  int           `yaml:"partitions"  env:"PARTITIONS"`
+	Replication int           `yaml:"replication" env:"REPLICATION"`
+	Retention   time.Duration `yaml:"retention"   env:"RETENTION"`
+}
+
+// Specs returns the topics to check or create on startup
+func (t Topics) Specs() []kadmin.TopicSpec {
+	roles := []struct {
+		name     string
+		settings TopicSettings
+	}{
+		{t.Request, t.RequestSettings},
+		{t.Reply, t.ReplySettings},
+		{t.DLQ, t.DLQSettings},
+		{t.Events, t.EventsSettings},
+	}
+
+	var specs []kadmin.TopicSpec
+	for _, r := range roles {
+		if r.name == "" {
+			continue
+		}
+
+		s := r.settings
+		if s.Partitions == 0 {
+			s.Partitions = t.Defaults.Partitions
+		}
+		if s.Replication == 0 {
+			s.Replication = t.Defaults.Replication
+		}
+		if s.Retention == 0 {
+			s.Retention = t.Defaults.Retention
+		}
+
+		specs = append(specs, kadmin.TopicSpec{
+			Name:              r.name,
+			Partitions:        s.Partitions,
+			ReplicationFactor: s.Replication,
+			Retention:         s.Retention,
+		})
+	}
+	return specs
+}
+
+type Worker struct {
+	Concurrency int `yaml:"concurrency" env:"WORKER_CONCURRENCY"`
+}
+
+type Auth struct {
+	JWTSecret     string        `yaml:"jwt_secret"      env:"JWT_SECRET" secret:"true"`
+	JWTSecretFile string        `yaml:"jwt_secret_file" env:"JWT_SECRET_FILE"`
+	TokenTTL      time.Duration `yaml:"token_ttl"       env:"JWT_TOKEN_TTL"`
+
+	// AdminUser and AdminPassword protect the /admin routes
+	AdminUser         string `yaml:"admin_user"          env:"ADMIN_USER"`
+	AdminPassword     string `yaml:"admin_password"      env:"ADMIN_PASSWORD" secret:"true"`
+	AdminPasswordFile string `yaml:"admin_password_file" env:"ADMIN_PASSWORD_FILE"`
+}
+
+type Locale struct {
+	// Default is used when the client accepts no supported language and
+	// has not chosen one
+	Default string `yaml:"default" env:"DEFAULT_LOCALE"`
+}
+
+// RateLimit sets the token buckets of the route groups. Admins can give
+// single users another limit for the /api routes, the API instances reload
+// those every RefreshInterval
+type RateLimit struct {
+	Enabled         bool          `yaml:"enabled"          env:"RATE_LIMIT_ENABLED"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"RATE_LIMIT_REFRESH_INTERVAL"`
+
+	Auth  RateLimitRule `yaml:"auth"  env:"RATE_LIMIT_AUTH_"`
+	API   RateLimitRule `yaml:"api"   env:"RATE_LIMIT_API_"`
+	Admin RateLimitRule `yaml:"admin" env:"RATE_LIMIT_ADMIN_"`
+}
+
+// RateLimitRule allows Burst requests at once and refills the bucket at
+// Requests per Period. A zero Burst means Requests
+type RateLimitRule struct {
+	Requests int           `yaml:"requests" env:"REQUESTS"`
+	Period   time.Duration `yaml:"period"   env:"PERIOD"`
+	Burst    int           `yaml:"burst"    env:"BURST"`
+}
+
+func (r RateLimitRule) Limit() ratelimit.Limit {
+	return ratelimit.Limit{Requests: r.Requests, Period: r.Period, Burst: r.Burst}
+}
+
+// Cache keeps book lists of users for TTL in an LRU of Size lists per API
+// instance. A zero TTL turns caching off
+type Cache struct {
+	TTL  time.Duration `yaml:"ttl"  env:"BOOKS_CACHE_TTL"`
+	Size int           `yaml:"size" env:"BOOKS_CACHE_SIZE"`
+}
+
+// Books sets how concurrent changes of a book are handled. With
+// RequireIfMatch a PATCH or DELETE without If-Match gets 428 instead of
+// overwriting whatever version is stored
+type Books struct {
+	RequireIfMatch bool `yaml:"require_if_match" env:"BOOKS_REQUIRE_IF_MATCH"`
+}
+
+func defaults() Config {
+	return Config{
+		HTTP: HTTP{
+			Addr:            ":8080",
+			DrainTimeout:    5 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Metrics: Metrics{Addr: ":9090"},
+		Tracing: Tracing{Exporter: "none"},
+		DB: DB{
+			Port:    5432,
+			SSLMode: "disable",
+			Migrate: true,
+		},
+		Kafka: Kafka{
+			ContentType:  models.KafkaContentTypeJSON,
+			CreateTopics: true,
+			Topics: Topics{
+				Defaults: TopicSettings{
+					Partitions:  3,
+					Replication: 1,
+					Retention:   7 * 24 * time.Hour,
+				},
+			},
+		},
+		Worker: Worker{Concurrency: 10},
+		Auth: Auth{
+			TokenTTL:  24 * time.Hour,
+			AdminUser: "SuperUser",
+		},
+		Locale: Locale{Default: "ru"},
+		RateLimit: RateLimit{
+			Enabled:         true,
+			RefreshInterval: 30 * time.Second,
+			Auth:            RateLimitRule{Requests: 10, Period: time.Minute, Burst: 5},
+			API:             RateLimitRule{Requests: 300, Period: time.Minute, Burst: 30},
+			Admin:           RateLimitRule{Requests: 60, Period: time.Minute, Burst: 10},
+		},
+		Cache: Cache{TTL: time.Minute, Size: 10000},
+	}
+}
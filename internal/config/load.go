@@ -0,0 +1,290 @@
+package config
+
+import (
+	kconf "bookstore-api/internal/perskafka/config"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// App selects the settings that are required
+type App string
+
+const (
+	AppAPI    App = "api"
+	AppWorker App = "worker"
+	// AppMigrate only needs the database
+	AppMigrate App = "migrate"
+	// AppKafkaTool only needs the Kafka connection, for bookctl kafka
+	AppKafkaTool App = "kafka"
+)
+
+const redacted = "[REDACTED]"
+
+// Load builds the config of app from args (without the program name), the
+// environment and the config file. The config flags are added to fs, so
+// callers can register flags of their own. All problems are reported together
+func Load(app App, fs *flag.FlagSet, args []string) (Config, error) {
+	cfg := defaults()
+	fields := walk(reflect.ValueOf(&cfg).Elem(), "", "")
+
+	configPath := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML config file, CONFIG_FILE by default")
+
+	flagValues := make(map[string]string)
+	for _, f := range fields {
+		usage := "env " + f.env
+		if f.env == "" {
+			usage = "no env variable"
+		}
+		fs.Func(f.path, usage, func(s string) error {
+			flagValues[f.path] = s
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	var problems []error
+
+	// a broken file is reported with the rest, env and flags still apply
+	if *configPath != "" {
+		if err := readFile(*configPath, &cfg); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if v, ok := os.LookupEnv(f.env); ok && v != "" {
+			if err := set(f.value, v); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %v", f.env, err))
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if v, ok := flagValues[f.path]; ok {
+			if err := set(f.value, v); err != nil {
+				problems = append(problems, fmt.Errorf("-%s: %v", f.path, err))
+			}
+		}
+	}
+
+	problems = append(problems, cfg.readSecrets()...)
+	problems = append(problems, cfg.validate(app)...)
+
+	if len(problems) > 0 {
+		return cfg, fmt.Errorf("invalid config:\n%w", errors.Join(problems...))
+	}
+	return cfg, nil
+}
+
+// MustLoad is Load for main: it exits with the errors, or after printing the
+// config with secrets redacted when -print-config is set
+func MustLoad(app App, args []string) Config {
+	fs := flag.NewFlagSet(string(app), flag.ContinueOnError)
+	printConfig := fs.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+
+	cfg, err := Load(app, fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if *printConfig && fs.Parsed() {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *printConfig {
+		os.Exit(0)
+	}
+
+	return cfg
+}
+
+func readFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer f.Close()
+
+	// unknown keys are most likely typos, report them
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// readSecrets replaces secrets with the content of their *_file setting
+func (c *Config) readSecrets() []error {
+	secrets := []struct {
+		name  string
+		value *string
+		file  string
+	}{
+		{"db.password", &c.DB.Password, c.DB.PasswordFile},
+		{"auth.jwt_secret", &c.Auth.JWTSecret, c.Auth.JWTSecretFile},
+		{"auth.admin_password", &c.Auth.AdminPassword, c.Auth.AdminPasswordFile},
+	}
+
+	var problems []error
+	for _, s := range secrets {
+		if s.file == "" {
+			continue
+		}
+		if *s.value != "" {
+			problems = append(problems, fmt.Errorf("%s: set either the value or the file", s.name))
+			continue
+		}
+
+		data, err := os.ReadFile(s.file)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", s.name, err))
+			continue
+		}
+		*s.value = strings.TrimSpace(string(data))
+	}
+
+	// the Kafka client reads its secret files itself when it connects, an
+	// unreadable one is reported now with everything else
+	kafkaFiles := []struct {
+		name string
+		file string
+	}{
+		{"kafka.sasl_username_file", c.Kafka.SASLUsernameFile},
+		{"kafka.sasl_password_file", c.Kafka.SASLPasswordFile},
+		{"kafka.ssl_key_password_file", c.Kafka.SSLKeyPasswordFile},
+	}
+	for _, f := range kafkaFiles {
+		if f.file == "" {
+			continue
+		}
+		if _, err := os.ReadFile(f.file); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", f.name, err))
+		}
+	}
+
+	return problems
+}
+
+// Print writes the config as YAML with secrets redacted
+func (c Config) Print(w io.Writer) error {
+	out := c
+	out.Kafka.Overrides = make(map[string]string, len(c.Kafka.Overrides))
+	for k, v := range c.Kafka.Overrides {
+		if strings.Contains(k, "password") || strings.Contains(k, "secret") {
+			v = redacted
+		}
+		out.Kafka.Overrides[k] = v
+	}
+
+	for _, f := range walk(reflect.ValueOf(&out).Elem(), "", "") {
+		if f.secret && f.value.String() != "" {
+			f.value.SetString(redacted)
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+type field struct {
+	path   string
+	env    string
+	secret bool
+	value  reflect.Value
+}
+
+// walk lists the settings of v. The env tag of a nested struct is a prefix
+// for the env names of its fields
+func walk(v reflect.Value, path, envPrefix string) []field {
+	var fields []field
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		env := sf.Tag.Get("env")
+		if env != "" {
+			env = envPrefix + env
+		}
+
+		fv := v.Field(i)
+		if sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, walk(fv, path+name+".", env)...)
+			continue
+		}
+
+		fields = append(fields, field{
+			path:   path + name,
+			env:    env,
+			secret: sf.Tag.Get("secret") == "true",
+			value:  fv,
+		})
+	}
+
+	return fields
+}
+
+func set(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", s)
+		}
+		v.SetBool(b)
+	case reflect.Map:
+		m, err := kconf.ParseOverrides(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes content to a file in a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func load(t *testing.T, app App, args ...string) (Config, error) {
+	t.Helper()
+	return Load(app, flag.NewFlagSet("test", flag.ContinueOnError), args)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yaml := writeFile(t, "config.yaml", `
+db:
+  host: from-file
+  port: 6000
+  user: file-user
+  name: file-db
+http:
+  drain_timeout: 7s
+`)
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		host string
+		port int
+		user string
+	}{
+		{
+			name: "file over defaults",
+			args: []string{"-config", yaml},
+			host: "from-file",
+			port: 6000,
+			user: "file-user",
+		},
+		{
+			name: "env over file",
+			env:  map[string]string{"DB_HOST": "from-env", "DB_PORT": "6001"},
+			args: []string{"-config", yaml},
+			host: "from-env",
+			port: 6001,
+			user: "file-user",
+		},
+		{
+			name: "flag over env",
+			env:  map[string]string{"DB_HOST": "from-env"},
+			args: []string{"-config", yaml, "-db.host", "from-flag"},
+			host: "from-flag",
+			port: 6000,
+			user: "file-user",
+		},
+		{
+			name: "config file from the environment",
+			env:  map[string]string{"CONFIG_FILE": yaml},
+			host: "from-file",
+			port: 6000,
+			user: "file-user",
+		},
+		{
+			name: "defaults without a file",
+			env:  map[string]string{"DB_HOST": "db", "DB_USER": "u", "DB_NAME": "n"},
+			host: "db",
+			port: 5432,
+			user: "u",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"CONFIG_FILE", "DB_HOST", "DB_PORT", "DB_USER", "DB_NAME"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			cfg, err := load(t, AppMigrate, tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.DB.Host != tt.host || cfg.DB.Port != tt.port || cfg.DB.User != tt.user {
+				t.Fatalf("db = %s:%d as %s, want %s:%d as %s",
+					cfg.DB.Host, cfg.DB.Port, cfg.DB.User, tt.host, tt.port, tt.user)
+			}
+			// untouched settings keep their defaults
+			if cfg.HTTP.ShutdownTimeout != 15*time.Second {
+				t.Fatalf("http.shutdown_timeout = %v, want the default", cfg.HTTP.ShutdownTimeout)
+			}
+		})
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	secret := writeFile(t, "db_password", "s3cret\n")
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name     string
+		args     []string
+		password string
+		problems []string
+	}{
+		{
+			name:     "file is read and trimmed",
+			args:     []string{"-db.password_file", secret},
+			password: "s3cret",
+		},
+		{
+			name:     "value without a file",
+			args:     []string{"-db.password", "plain"},
+			password: "plain",
+		},
+		{
+			name:     "value and file",
+			args:     []string{"-db.password", "plain", "-db.password_file", secret},
+			problems: []string{"db.password: set either the value or the file"},
+		},
+		{
+			name: "every unreadable file is reported",
+			args: []string{
+				"-db.password_file", missing,
+				"-kafka.sasl_password_file", missing,
+				"-config", missing,
+			},
+			problems: []string{"db.password:", "kafka.sasl_password_file:", "config file:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", "")
+			args := append([]string{"-db.host", "db", "-db.user", "u", "-db.name", "n"}, tt.args...)
+
+			cfg, err := load(t, AppMigrate, args...)
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cfg.DB.Password != tt.password {
+					t.Fatalf("db.password = %q, want %q", cfg.DB.Password, tt.password)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("no error")
+			}
+			for _, p := range tt.problems {
+				if !strings.Contains(err.Error(), p) {
+					t.Errorf("error %q does not mention %q", err, p)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	cfg := defaults()
+	cfg.Kafka.Topics.Defaults = TopicSettings{}
+	cfg.Kafka.Topics.RequestSettings.Partitions = -1
+	cfg.Kafka.Topics.ReplySettings.Partitions = -1
+	cfg.Kafka.Topics.DLQSettings.Partitions = -1
+	cfg.Kafka.Topics.EventsSettings.Partitions = -1
+	cfg.RateLimit.Auth = RateLimitRule{}
+	cfg.RateLimit.API = RateLimitRule{}
+	cfg.RateLimit.Admin = RateLimitRule{}
+
+	join := func(errs []error) string {
+		var b strings.Builder
+		for _, err := range errs {
+			b.WriteString(err.Error() + "\n")
+		}
+		return b.String()
+	}
+
+	first := join(cfg.validate(AppAPI))
+	for range 20 {
+		if got := join(cfg.validate(AppAPI)); got != first {
+			t.Fatalf("problems changed order:\n%s\nthen\n%s", first, got)
+		}
+	}
+}
+
+func TestValidateApps(t *testing.T) {
+	tests := []struct {
+		app      App
+		required []string
+		skipped  []string
+	}{
+		{AppMigrate, []string{"db.host"}, []string{"kafka.brokers", "auth.jwt_secret"}},
+		{AppKafkaTool, []string{"kafka.brokers"}, []string{"db.host", "kafka.group_id"}},
+		{AppWorker, []string{"db.host", "kafka.brokers", "kafka.topics.events"}, []string{"auth.jwt_secret"}},
+		{AppAPI, []string{"db.host", "kafka.brokers", "auth.jwt_secret"}, []string{"kafka.topics.events"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.app), func(t *testing.T) {
+			var got strings.Builder
+			for _, err := range defaults().validate(tt.app) {
+				got.WriteString(err.Error() + "\n")
+			}
+
+			for _, r := range tt.required {
+				if !strings.Contains(got.String(), r+" is required") {
+					t.Errorf("%s is not required:\n%s", r, got.String())
+				}
+			}
+			for _, s := range tt.skipped {
+				if strings.Contains(got.String(), s) {
+					t.Errorf("%s is checked:\n%s", s, got.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"bookstore-api/internal/lib/i18n"
+	"bookstore-api/internal/models"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+func (c Config) validate(app App) []error {
+	var problems []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+
+	switch app {
+	case AppAPI:
+		check(c.HTTP.Addr != "", "http.addr is required")
+		check(c.Auth.JWTSecret != "", "auth.jwt_secret is required (JWT_SECRET or JWT_SECRET_FILE)")
+		check(c.Auth.TokenTTL > 0, "auth.token_ttl must be positive")
+		check(c.Auth.AdminUser != "", "auth.admin_user is required")
+		check(c.Auth.AdminPassword != "", "auth.admin_password is required (ADMIN_PASSWORD or ADMIN_PASSWORD_FILE)")
+		check(i18n.Supported(c.Locale.Default), "locale.default %q: want one of %s",
+			c.Locale.Default, strings.Join(i18n.Locales(), ", "))
+		check(c.Cache.TTL >= 0, "cache.ttl must not be negative")
+		check(c.Cache.TTL == 0 || c.Cache.Size > 0, "cache.size must be positive")
+		if c.RateLimit.Enabled {
+			check(c.RateLimit.RefreshInterval > 0, "rate_limit.refresh_interval must be positive")
+			rules := map[string]RateLimitRule{
+				"auth":  c.RateLimit.Auth,
+				"api":   c.RateLimit.API,
+				"admin": c.RateLimit.Admin,
+			}
+			for _, name := range slices.Sorted(maps.Keys(rules)) {
+				check(rules[name].Limit().Valid(),
+					"rate_limit.%s: requests and period must be positive, burst must not be negative", name)
+			}
+		}
+	case AppWorker:
+		check(c.Metrics.Addr != "", "metrics.addr is required")
+		check(c.Worker.Concurrency > 0, "worker.concurrency must be positive")
+	}
+
+	k := c.Kafka
+	// bookctl kafka only connects to the brokers
+	if app == AppKafkaTool {
+		check(k.Brokers != "", "kafka.brokers is required")
+		check(validSecurityProtocol(k.SecurityProtocol),
+			"kafka.security_protocol %q: want plaintext, ssl, sasl_plaintext or sasl_ssl", k.SecurityProtocol)
+		return problems
+	}
+
+	check(c.HTTP.DrainTimeout >= 0, "http.drain_timeout must not be negative")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout must be positive")
+
+	check(c.DB.Host != "", "db.host is required")
+	check(c.DB.Port > 0 && c.DB.Port < 65536, "db.port %d is out of range", c.DB.Port)
+	check(c.DB.User != "", "db.user is required")
+	check(c.DB.Name != "", "db.name is required")
+
+	if app == AppMigrate {
+		return problems
+	}
+
+	check(oneOf(c.Tracing.Exporter, "otlp", "stdout", "none"),
+		"tracing.exporter %q: want otlp, stdout or none", c.Tracing.Exporter)
+
+	check(k.Brokers != "", "kafka.brokers is required")
+	check(k.GroupID != "", "kafka.group_id is required")
+	check(oneOf(k.ContentType, models.KafkaContentTypeJSON, models.KafkaContentTypeProtobuf),
+		"kafka.content_type %q: want %s or %s", k.ContentType, models.KafkaContentTypeJSON, models.KafkaContentTypeProtobuf)
+	check(validSecurityProtocol(k.SecurityProtocol),
+		"kafka.security_protocol %q: want plaintext, ssl, sasl_plaintext or sasl_ssl", k.SecurityProtocol)
+	check(k.Topics.Request != "", "kafka.topics.request is required")
+	check(k.Topics.Reply != "", "kafka.topics.reply is required")
+	// the worker relays the outbox there, without it events pile up
+	check(app != AppWorker || k.Topics.Events != "", "kafka.topics.events is required")
+
+	d := k.Topics.Defaults
+	check(d.Partitions > 0, "kafka.topics.defaults.partitions must be positive")
+	check(d.Replication > 0, "kafka.topics.defaults.replication must be positive")
+	check(d.Retention > 0, "kafka.topics.defaults.retention must be positive")
+	settings := map[string]TopicSettings{
+		"request_settings": k.Topics.RequestSettings,
+		"reply_settings":   k.Topics.ReplySettings,
+		"dlq_settings":     k.Topics.DLQSettings,
+		"events_settings":  k.Topics.EventsSettings,
+	}
+	// sorted, so the problems come in the same order every run
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		s := settings[name]
+		check(s.Partitions >= 0 && s.Replication >= 0 && s.Retention >= 0,
+			"kafka.topics.%s must not be negative", name)
+	}
+
+	return problems
+}
+
+func validSecurityProtocol(p string) bool {
+	return p == "" || oneOf(strings.ToLower(p), "plaintext", "ssl", "sasl_plaintext", "sasl_ssl")
+}
+
+func oneOf(v string, allowed ...string) bool {
+	for _, a := range allowed {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
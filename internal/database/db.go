@@ -1,45 +1,77 @@
 package database
 
 import (
-	"bookstore-api/internal/models"
+	"bookstore-api/internal/metrics"
+	"context"
 	"fmt"
 	"log"
-	"os"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	gormtracing "gorm.io/plugin/opentelemetry/tracing"
 )
 
 var db *gorm.DB
 
-func InitDB() *gorm.DB {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-	)
+// InitDB connects to postgres and applies pending migrations, or with
+// migrate false only checks that none are pending. debug logs every query
+func InitDB(dsn string, debug, migrate bool) *gorm.DB {
+	var err error
 
+	db, err = Open(dsn, debug)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if migrate {
+		if err := migrator.Up(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		pending, err := migrator.Pending(context.Background())
+		if err != nil {
+			log.Fatal(err)
+		}
+		if pending > 0 {
+			log.Fatalf("%d database migrations are pending, run bookctl migrate up", pending)
+		}
+	}
+
+	return db
+}
+
+// Open connects to postgres without touching the schema
+func Open(dsn string, debug bool) (*gorm.DB, error) {
 	cfg := &gorm.Config{}
 
-	if os.Getenv("DEBUG_MODE") == "true" {
+	if debug {
 		cfg.Logger = logger.Default.LogMode(logger.Info)
 	} else {
 		cfg.Logger = logger.Default.LogMode(logger.Silent)
 	}
 
-	var err error
-
-	db, err = gorm.Open(postgres.Open(dsn), cfg)
+	db, err := gorm.Open(postgres.Open(dsn), cfg)
 	if err != nil {
-		log.Fatal("Could not connect to DB")
+		return nil, fmt.Errorf("could not connect to DB: %w", err)
 	}
 
-	if err := db.AutoMigrate(&models.User{}, &models.Book{}); err != nil {
-		log.Fatal("Error when creation DB")
+	// query spans join the trace of the context passed with WithContext.
+	// Values are left out, they may contain password hashes
+	if err := db.Use(gormtracing.NewPlugin(
+		gormtracing.WithoutQueryVariables(),
+		gormtracing.WithoutMetrics(),
+	)); err != nil {
+		return nil, fmt.Errorf("could not enable DB tracing: %w", err)
+	}
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("could not enable DB metrics: %w", err)
 	}
 
-	return db
+	return db, nil
 }
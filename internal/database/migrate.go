@@ -0,0 +1,347 @@
+package database
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/sl"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the pg_advisory_lock key held while migrating, so
+// replicas starting together apply every migration once
+const migrationLockID int64 = 0x626f6f6b73746f72
+
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one schema change. Files are named
+// <version>_<name>.up.sql and <version>_<name>.down.sql
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Migration states reported by Status
+const (
+	MigrationApplied  = "applied"
+	MigrationPending  = "pending"
+	MigrationModified = "modified"
+	MigrationMissing  = "missing"
+)
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	State     string
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator applies the embedded migrations and records them in
+// schema_migrations with the checksum of the up script
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrMigration, err)
+	}
+
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: sqlDB, migrations: migrations}, nil
+}
+
+func loadMigrations(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrMigration, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		m := migrationName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("%w: unexpected file %s", errs.ErrMigration, e.Name())
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+
+		data, err := fs.ReadFile(files, "migrations/"+e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errs.ErrMigration, err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("%w: version %d is used by %s and %s", errs.ErrMigration, version, mig.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			sum := sha256.Sum256(data)
+			mig.Up = string(data)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("%w: migration %d_%s needs both up and down files", errs.ErrMigration, mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Up applies every pending migration in version order. It refuses to run
+// when an applied migration was changed afterwards
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx,
+					`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+					mig.Version, mig.Name, mig.Checksum,
+				)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("%w: %d_%s up: %v", errs.ErrMigration, mig.Version, mig.Name, err)
+			}
+
+			slog.Info("migration applied", "version", mig.Version, "name", mig.Name)
+		}
+
+		return nil
+	})
+}
+
+// Down reverts the last steps applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		known := make(map[int64]Migration, len(m.migrations))
+		for _, mig := range m.migrations {
+			known[mig.Version] = mig
+		}
+
+		for i := 0; i < steps && i < len(versions); i++ {
+			mig, ok := known[versions[i]]
+			if !ok {
+				return fmt.Errorf("%w: migration %d_%s is applied but unknown to this build",
+					errs.ErrMigration, versions[i], applied[versions[i]].name)
+			}
+
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("%w: %d_%s down: %v", errs.ErrMigration, mig.Version, mig.Name, err)
+			}
+
+			slog.Info("migration reverted", "version", mig.Version, "name", mig.Name)
+		}
+
+		return nil
+	})
+}
+
+// Status lists the embedded migrations and the applied ones this build
+// doesn't know, in version order
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			st := MigrationStatus{Version: mig.Version, Name: mig.Name, State: MigrationPending}
+			if a, ok := applied[mig.Version]; ok {
+				st.State = MigrationApplied
+				if a.checksum != mig.Checksum {
+					st.State = MigrationModified
+				}
+				st.AppliedAt = &a.appliedAt
+				delete(applied, mig.Version)
+			}
+			statuses = append(statuses, st)
+		}
+
+		for v, a := range applied {
+			statuses = append(statuses, MigrationStatus{
+				Version:   v,
+				Name:      a.name,
+				State:     MigrationMissing,
+				AppliedAt: &a.appliedAt,
+			})
+		}
+		sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// Pending returns the number of migrations that are not applied yet
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, st := range statuses {
+		switch st.State {
+		case MigrationPending:
+			pending++
+		case MigrationModified:
+			return 0, fmt.Errorf("%w: applied migration %d_%s was modified", errs.ErrMigration, st.Version, st.Name)
+		}
+	}
+	return pending, nil
+}
+
+// locked runs fn on one connection holding the migration lock. Advisory
+// locks belong to a session, so everything has to use that connection
+func (m *Migrator) locked(ctx context.Context, fn func(*sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrMigration, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("%w: lock: %v", errs.ErrMigration, err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			slog.Error("database.Migrator unlock", sl.Error(err))
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       text        NOT NULL,
+		checksum   text        NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrMigration, err)
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrMigration, err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var v int64
+		var a appliedMigration
+		if err := rows.Scan(&v, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", errs.ErrMigration, err)
+		}
+		applied[v] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrMigration, err)
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) verify(applied map[int64]appliedMigration) error {
+	for _, mig := range m.migrations {
+		if a, ok := applied[mig.Version]; ok && a.checksum != mig.Checksum {
+			return fmt.Errorf("%w: applied migration %d_%s was modified, add a new migration instead",
+				errs.ErrMigration, mig.Version, mig.Name)
+		}
+	}
+	return nil
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(*sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
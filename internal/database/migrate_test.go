@@ -0,0 +1,110 @@
+package database
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(sql string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(sql)} }
+
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int64
+		wantErr  bool
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"migrations/0010_b.up.sql":   file("b"),
+				"migrations/0010_b.down.sql": file("b"),
+				"migrations/0002_a.up.sql":   file("a"),
+				"migrations/0002_a.down.sql": file("a"),
+			},
+			versions: []int64{2, 10},
+		},
+		{
+			name: "missing down",
+			files: fstest.MapFS{
+				"migrations/0001_a.up.sql": file("a"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "version used twice",
+			files: fstest.MapFS{
+				"migrations/0001_a.up.sql":   file("a"),
+				"migrations/0001_a.down.sql": file("a"),
+				"migrations/0001_b.up.sql":   file("b"),
+				"migrations/0001_b.down.sql": file("b"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "unexpected file",
+			files: fstest.MapFS{
+				"migrations/README.md": file("a"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadMigrations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var versions []int64
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+			}
+			if !slices.Equal(versions, tt.versions) {
+				t.Errorf("versions = %v, want %v", versions, tt.versions)
+			}
+		})
+	}
+}
+
+// every table is created by the migration of the change that needs it, the
+// baseline only has what AutoMigrate made before
+func TestEmbeddedMigrationTables(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createTable := regexp.MustCompile(`CREATE TABLE IF NOT EXISTS (\w+)`)
+	createdBy := make(map[string]string)
+	for _, m := range migrations {
+		for _, match := range createTable.FindAllStringSubmatch(m.Up, -1) {
+			if prev, ok := createdBy[match[1]]; ok {
+				t.Errorf("%s is created by %s and %s", match[1], prev, m.Name)
+			}
+			createdBy[match[1]] = m.Name
+		}
+	}
+
+	want := map[string]string{
+		"users":                "baseline",
+		"books":                "baseline",
+		"idempotency_keys":     "idempotency_keys",
+		"processed_requests":   "idempotency_keys",
+		"outbox_events":        "outbox_events",
+		"jobs":                 "jobs",
+		"rate_limit_overrides": "rate_limit_overrides",
+		"book_revisions":       "book_revisions",
+	}
+	for table, name := range want {
+		if createdBy[table] != name {
+			t.Errorf("%s is created by %q, want %q", table, createdBy[table], name)
+		}
+	}
+	if len(createdBy) != len(want) {
+		t.Errorf("tables = %v, want %v", createdBy, want)
+	}
+}
@@ -0,0 +1,18 @@
+-- Schema previously created by GORM AutoMigrate. IF NOT EXISTS lets
+-- databases created that way adopt the migrations without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+    id       bigserial PRIMARY KEY,
+    username text,
+    password text,
+    CONSTRAINT uni_users_username UNIQUE (username)
+);
+
+CREATE TABLE IF NOT EXISTS books (
+    id      bigserial PRIMARY KEY,
+    title   text,
+    author  text,
+    price   bigint,
+    user_id bigint,
+    CONSTRAINT fk_users_books FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
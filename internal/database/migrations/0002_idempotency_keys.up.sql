@@ -0,0 +1,29 @@
+-- Idempotency-Key of write requests with the stored response, and the keyed
+-- Kafka requests the worker has applied. IF NOT EXISTS lets databases
+-- created by AutoMigrate adopt it
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id      bigint,
+    key          varchar(255),
+    request_hash varchar(64) NOT NULL,
+    completed    boolean     NOT NULL DEFAULT false,
+    status_code  bigint,
+    headers      jsonb,
+    response     bytea,
+    created_at   timestamptz,
+    expires_at   timestamptz NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+CREATE TABLE IF NOT EXISTS processed_requests (
+    user_id    bigint,
+    key        varchar(255),
+    method     text,
+    created_at timestamptz,
+    PRIMARY KEY (user_id, key)
+);
+CREATE INDEX IF NOT EXISTS idx_processed_requests_created_at ON processed_requests (created_at);
+
+-- result replayed when the request is redelivered or retried, tables made
+-- by AutoMigrate lack it
+ALTER TABLE processed_requests ADD COLUMN IF NOT EXISTS result bytea;
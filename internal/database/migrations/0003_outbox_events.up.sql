@@ -0,0 +1,18 @@
+-- Domain events written with the change they describe, published by the
+-- outbox relay of the worker. IF NOT EXISTS lets databases created by
+-- AutoMigrate adopt it
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id             bigserial PRIMARY KEY,
+    aggregate_type varchar(32) NOT NULL,
+    aggregate_id   varchar(64) NOT NULL,
+    event_type     varchar(32) NOT NULL,
+    payload        bytea       NOT NULL,
+    created_at     timestamptz NOT NULL,
+    sent_at        timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_sent_at ON outbox_events (sent_at);
+
+-- the relay claims a batch until claimed_until and publishes it outside the
+-- transaction, a claim left by a stopped relay expires
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS claimed_by varchar(36);
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS claimed_until timestamptz;
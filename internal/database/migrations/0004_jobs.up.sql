@@ -0,0 +1,15 @@
+-- Status of writes sent with Prefer: respond-async. IF NOT EXISTS lets
+-- databases created by AutoMigrate adopt it
+CREATE TABLE IF NOT EXISTS jobs (
+    id            varchar(36) PRIMARY KEY,
+    user_id       bigint NOT NULL,
+    method        text   NOT NULL,
+    status        text   NOT NULL,
+    error_code    text,
+    error_message text,
+    result        bytea,
+    created_at    timestamptz,
+    updated_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_user_id ON jobs (user_id);
+CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs (created_at);
@@ -0,0 +1,2 @@
+-- Language chosen by the user, empty means Accept-Language decides
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale varchar(8) NOT NULL DEFAULT '';
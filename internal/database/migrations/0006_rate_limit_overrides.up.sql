@@ -0,0 +1,9 @@
+-- Rate limits set by admins for single users, period is in nanoseconds
+CREATE TABLE IF NOT EXISTS rate_limit_overrides (
+    user_id    bigint PRIMARY KEY,
+    requests   bigint      NOT NULL,
+    period     bigint      NOT NULL,
+    burst      bigint      NOT NULL DEFAULT 0,
+    updated_at timestamptz NOT NULL,
+    CONSTRAINT fk_rate_limit_overrides_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
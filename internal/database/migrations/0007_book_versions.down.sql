@@ -0,0 +1,3 @@
+ALTER TABLE books DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE books DROP COLUMN IF EXISTS created_at;
+ALTER TABLE books DROP COLUMN IF EXISTS version;
@@ -0,0 +1,5 @@
+-- Optimistic locking: updates and deletes may require the version the
+-- client has seen. Existing books start at version 1
+ALTER TABLE books ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
+ALTER TABLE books ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now();
+ALTER TABLE books ADD COLUMN IF NOT EXISTS updated_at timestamptz NOT NULL DEFAULT now();
@@ -0,0 +1,25 @@
+-- History of books: a snapshot after every change, or before the deletion.
+-- Revisions outlive the book, so there is no foreign key to books
+CREATE TABLE IF NOT EXISTS book_revisions (
+    id         bigserial PRIMARY KEY,
+    book_id    bigint      NOT NULL,
+    user_id    bigint      NOT NULL,
+    version    bigint      NOT NULL,
+    operation  varchar(16) NOT NULL,
+    title      text        NOT NULL,
+    author     text        NOT NULL,
+    price      bigint      NOT NULL,
+    actor      varchar(64) NOT NULL,
+    reason     varchar(255) NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT fk_book_revisions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_revisions_book ON book_revisions (user_id, book_id, id);
+
+-- books written before the history start with their current state
+INSERT INTO book_revisions (book_id, user_id, version, operation, title, author, price, actor, reason, created_at)
+SELECT id, user_id, version, 'create', COALESCE(title, ''), COALESCE(author, ''), COALESCE(price, 0),
+       'system', 'Recorded when history was enabled', updated_at
+FROM books
+WHERE user_id IS NOT NULL;
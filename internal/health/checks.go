@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// DB pings the database
+func DB(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// KafkaBrokers asks a broker for the cluster metadata through p
+func KafkaBrokers(p *kafka.Producer) Check {
+	return func(ctx context.Context) error {
+		timeout := checkTimeout
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+
+		md, err := p.GetMetadata(nil, false, int(timeout.Milliseconds()))
+		if err != nil {
+			return err
+		}
+		if len(md.Brokers) == 0 {
+			return errors.New("no brokers in metadata")
+		}
+		return nil
+	}
+}
+
+// KafkaSubscription checks that c is subscribed to topic. With
+// needAssignment it also has to own partitions, which is false during a
+// rebalance
+func KafkaSubscription(c *kafka.Consumer, topic string, needAssignment bool) Check {
+	return func(ctx context.Context) error {
+		topics, err := c.Subscription()
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(topics, topic) {
+			return fmt.Errorf("not subscribed to %s", topic)
+		}
+
+		if !needAssignment {
+			return nil
+		}
+		assigned, err := c.Assignment()
+		if err != nil {
+			return err
+		}
+		if len(assigned) == 0 {
+			return fmt.Errorf("no partitions of %s assigned", topic)
+		}
+		return nil
+	}
+}
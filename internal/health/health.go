@@ -0,0 +1,162 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Service states reported by /readyz besides the component results
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusStarting    = "starting"
+	StatusDraining    = "draining"
+)
+
+const checkTimeout = 5 * time.Second
+
+// Check reports whether a dependency is usable
+type Check func(ctx context.Context) error
+
+type component struct {
+	name  string
+	check Check
+}
+
+// Health serves the liveness and readiness endpoints. A new Health is
+// starting, it turns ready with Ready and stays unavailable once Drain is
+// called, so load balancers stop sending traffic before shutdown
+type Health struct {
+	components []component
+	state      atomic.Value
+}
+
+func New() *Health {
+	h := &Health{}
+	h.state.Store(StatusStarting)
+	return h
+}
+
+// Add registers a component checked by /readyz
+func (h *Health) Add(name string, check Check) {
+	h.components = append(h.components, component{name: name, check: check})
+}
+
+// Ready marks the end of startup
+func (h *Health) Ready() {
+	h.state.CompareAndSwap(StatusStarting, StatusOK)
+}
+
+// Drain makes /readyz fail until the process exits
+func (h *Health) Drain() {
+	h.state.Store(StatusDraining)
+}
+
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+// LiveHandler answers 200 while the process is able to serve requests
+func (h *Health) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Report{Status: StatusOK})
+	})
+}
+
+// ReadyHandler runs every check and answers 503 when one of them fails or
+// the service is starting or draining
+func (h *Health) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := h.state.Load().(string)
+		if state != StatusOK {
+			writeJSON(w, http.StatusServiceUnavailable, Report{Status: state})
+			return
+		}
+
+		report := h.Check(r.Context())
+		code := http.StatusOK
+		if report.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, report)
+	})
+}
+
+// Check runs the component checks in parallel
+func (h *Health) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	report := Report{
+		Status:     StatusOK,
+		Components: make(map[string]ComponentStatus, len(h.components)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range h.components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := c.check(ctx)
+			st := ComponentStatus{
+				Status:    StatusOK,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				st.Status = StatusUnavailable
+				st.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[c.name] = st
+			if err != nil {
+				report.Status = StatusUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// Cached runs check at most once per ttl and returns the last result in
+// between. Meant for checks that are too expensive for every probe
+func Cached(ttl time.Duration, check Check) Check {
+	var mu sync.Mutex
+	var last time.Time
+	var lastErr error
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !last.IsZero() && time.Since(last) < ttl {
+			return lastErr
+		}
+		lastErr = check(ctx)
+		last = time.Now()
+		return lastErr
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
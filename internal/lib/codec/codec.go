@@ -0,0 +1,60 @@
+package codec
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/models"
+	"encoding/json"
+	"fmt"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Codec encodes Kafka envelopes and their payloads. Payloads are encoded
+// with the same codec as the envelope carrying them
+type Codec interface {
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var codecs = map[string]Codec{
+	models.KafkaContentTypeJSON:     JSON{},
+	models.KafkaContentTypeProtobuf: Protobuf{},
+}
+
+// ForContentType returns the codec registered for ct
+func ForContentType(ct string) (Codec, error) {
+	c, ok := codecs[ct]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported content type %q", errs.ErrInvalidMsg, ct)
+	}
+	return c, nil
+}
+
+// FromHeaders picks the codec by the content-type header. Messages written
+// before the header existed are JSON
+func FromHeaders(headers []kafka.Header) (Codec, error) {
+	for _, h := range headers {
+		if h.Key == models.KafkaContentTypeHeader {
+			return ForContentType(string(h.Value))
+		}
+	}
+	return JSON{}, nil
+}
+
+// ToJSON re-encodes a payload of cd as JSON by decoding it into v. JSON
+// payloads are returned as they are
+func ToJSON(cd Codec, v interface{}, raw []byte) (json.RawMessage, error) {
+	if len(raw) == 0 || cd.ContentType() == models.KafkaContentTypeJSON {
+		return raw, nil
+	}
+	if v == nil {
+		return nil, fmt.Errorf("%w: no payload type to decode %s", errs.ErrInvalidMsg, cd.ContentType())
+	}
+
+	if err := cd.Unmarshal(raw, v); err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrInvalidMsg, err)
+	}
+
+	return json.Marshal(v)
+}
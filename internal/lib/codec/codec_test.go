@@ -0,0 +1,183 @@
+package codec
+
+import (
+	"bookstore-api/internal/models"
+	"bookstore-api/internal/models/pb"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var benchCodecs = []Codec{JSON{}, Protobuf{}}
+
+var benchSizes = []int{10, 1000, 5000}
+
+// userBooks builds a reply to GetUserBooks with n books shaped like the
+// ones stored by the API
+func userBooks(n int) models.GetUserBooksResponse {
+	created := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	books := make([]models.Book, n)
+	for i := range books {
+		books[i] = models.Book{
+			ID:        uint(i + 1),
+			Title:     fmt.Sprintf("Война и мир, том %d", i%4+1),
+			Author:    "Л. Н. Толстой",
+			Price:     uint(500 + i%1000),
+			UserID:    7,
+			Version:   uint(i%5 + 1),
+			CreatedAt: created,
+			UpdatedAt: created.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	return models.GetUserBooksResponse{Books: books}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	for _, cd := range benchCodecs {
+		for _, n := range benchSizes {
+			payload := userBooks(n)
+			raw, err := cd.Marshal(payload)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.Run(fmt.Sprintf("%s/books=%d", cd.ContentType(), n), func(b *testing.B) {
+				b.SetBytes(int64(len(raw)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := cd.Marshal(payload); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	for _, cd := range benchCodecs {
+		for _, n := range benchSizes {
+			raw, err := cd.Marshal(userBooks(n))
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.Run(fmt.Sprintf("%s/books=%d", cd.ContentType(), n), func(b *testing.B) {
+				b.SetBytes(int64(len(raw)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					var out models.GetUserBooksResponse
+					if err := cd.Unmarshal(raw, &out); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	sent := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		v    any
+		into func() any
+	}{
+		{
+			name: "request",
+			v: models.KafkaBookRequest{
+				SchemaVersion: models.KafkaSchemaVersion,
+				MessageID:     "m1",
+				Method:        models.RevertBookMethod,
+				Type:          models.KafkaRequestType,
+				RelationID:    "r1",
+				UserID:        7,
+				SentAt:        sent,
+				Payload:       []byte(`{"id":1}`),
+			},
+			into: func() any { return &models.KafkaBookRequest{} },
+		},
+		{
+			name: "request without sent_at",
+			v: models.KafkaBookRequest{
+				SchemaVersion: models.KafkaSchemaVersion,
+				Method:        models.PingMethod,
+				Payload:       []byte(`{}`),
+			},
+			into: func() any { return &models.KafkaBookRequest{} },
+		},
+		{
+			name: "response without sent_at",
+			v: models.KafkaBookResponse{
+				SchemaVersion: models.KafkaSchemaVersion,
+				Method:        models.GetBookMethod,
+				Error:         &models.KafkaError{Code: "NOT_FOUND", Message: "no such book"},
+			},
+			into: func() any { return &models.KafkaBookResponse{} },
+		},
+		{
+			name: "revert",
+			v:    models.RevertBook{ID: 1, UserID: 7, Revision: 3, Version: 2},
+			into: func() any { return &models.RevertBook{} },
+		},
+		{
+			name: "book without timestamps",
+			v:    models.Book{ID: 1, Title: "Dune", Author: "Herbert", Price: 10, UserID: 7, Version: 1},
+			into: func() any { return &models.Book{} },
+		},
+	}
+
+	for _, cd := range benchCodecs {
+		for _, tt := range tests {
+			t.Run(cd.ContentType()+"/"+tt.name, func(t *testing.T) {
+				raw, err := cd.Marshal(tt.v)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got := tt.into()
+				if err := cd.Unmarshal(raw, got); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(reflect.ValueOf(got).Elem().Interface(), tt.v) {
+					t.Errorf("round trip = %+v, want %+v", reflect.ValueOf(got).Elem().Interface(), tt.v)
+				}
+			})
+		}
+	}
+}
+
+// producers other than this codec may leave sent_at unset, it must decode
+// to the zero time and not to the Unix epoch
+func TestProtobufUnsetSentAt(t *testing.T) {
+	raw, err := proto.Marshal(&pb.BookRequest{Method: models.PingMethod})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req models.KafkaBookRequest
+	if err := (Protobuf{}).Unmarshal(raw, &req); err != nil {
+		t.Fatal(err)
+	}
+	if !req.SentAt.IsZero() {
+		t.Errorf("SentAt = %v, want the zero time", req.SentAt)
+	}
+
+	raw, err = proto.Marshal(&pb.BookResponse{Method: models.PingMethod})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res models.KafkaBookResponse
+	if err := (Protobuf{}).Unmarshal(raw, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.SentAt.IsZero() {
+		t.Errorf("SentAt = %v, want the zero time", res.SentAt)
+	}
+}
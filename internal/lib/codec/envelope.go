@@ -0,0 +1,49 @@
+package codec
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/schema"
+	"bookstore-api/internal/models"
+	"fmt"
+	"strconv"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Headers describe how a message produced with cd is encoded
+func Headers(cd Codec) []kafka.Header {
+	return []kafka.Header{
+		{Key: models.KafkaContentTypeHeader, Value: []byte(cd.ContentType())},
+		{Key: models.KafkaSchemaVersionHeader, Value: []byte(strconv.Itoa(models.KafkaSchemaVersion))},
+	}
+}
+
+// DecodeEnvelope picks the codec of msg and reads the fields needed to
+// route it. Messages of an incompatible major schema version are rejected
+func DecodeEnvelope(msg *kafka.Message) (Codec, models.KafkaEnvelope, error) {
+	var env models.KafkaEnvelope
+
+	cd, err := FromHeaders(msg.Headers)
+	if err != nil {
+		return nil, env, err
+	}
+
+	if err := cd.Unmarshal(msg.Value, &env); err != nil {
+		return nil, env, fmt.Errorf("%w: %v", errs.ErrInvalidMsg, err)
+	}
+
+	if env.SchemaVersion != models.KafkaSchemaVersion {
+		return nil, env, fmt.Errorf("%w: unsupported schema version %d", errs.ErrInvalidMsg, env.SchemaVersion)
+	}
+
+	return cd, env, nil
+}
+
+// Validate applies JSON Schema to JSON messages, protobuf messages are
+// checked by their own definitions while decoding
+func Validate(cd Codec, name string, raw []byte) error {
+	if cd.ContentType() != models.KafkaContentTypeJSON {
+		return nil
+	}
+	return schema.Validate(name, raw)
+}
@@ -0,0 +1,20 @@
+package codec
+
+import (
+	"bookstore-api/internal/models"
+	"encoding/json"
+)
+
+type JSON struct{}
+
+func (JSON) ContentType() string {
+	return models.KafkaContentTypeJSON
+}
+
+func (JSON) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSON) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
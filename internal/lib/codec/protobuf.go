@@ -0,0 +1,482 @@
+package codec
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/models"
+	"bookstore-api/internal/models/pb"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Protobuf encodes messages with the definitions from
+// proto/bookstore/kafka/v1/kafka.proto
+type Protobuf struct{}
+
+func (Protobuf) ContentType() string {
+	return models.KafkaContentTypeProtobuf
+}
+
+func (Protobuf) Marshal(v interface{}) ([]byte, error) {
+	var msg proto.Message
+
+	switch m := v.(type) {
+	case *models.KafkaBookRequest:
+		msg = requestToPB(*m)
+	case models.KafkaBookRequest:
+		msg = requestToPB(m)
+	case *models.KafkaBookResponse:
+		msg = responseToPB(*m)
+	case models.KafkaBookResponse:
+		msg = responseToPB(m)
+	case *models.GetAllBooksRequest, models.GetAllBooksRequest:
+		msg = &pb.GetAllBooksRequest{}
+	case *models.PingRequest, models.PingRequest:
+		msg = &pb.PingRequest{}
+	case *models.GetAllBooksResponse:
+		msg = usersToPB(*m)
+	case models.GetAllBooksResponse:
+		msg = usersToPB(m)
+	case *models.GetUserBooksRequest:
+		msg = getUserBooksToPB(*m)
+	case models.GetUserBooksRequest:
+		msg = getUserBooksToPB(m)
+	case *models.GetUserBooksResponse:
+		msg = booksToPB(*m)
+	case models.GetUserBooksResponse:
+		msg = booksToPB(m)
+	case *models.Book:
+		msg = bookToPB(*m)
+	case models.Book:
+		msg = bookToPB(m)
+	case *models.DeleteBook:
+		msg = &pb.DeleteBook{Id: uint64(m.ID), UserId: uint64(m.UserID), Version: uint64(m.Version)}
+	case models.DeleteBook:
+		msg = &pb.DeleteBook{Id: uint64(m.ID), UserId: uint64(m.UserID), Version: uint64(m.Version)}
+	case *models.RevertBook:
+		msg = revertToPB(*m)
+	case models.RevertBook:
+		msg = revertToPB(m)
+	case *models.GetBookRequest:
+		msg = &pb.GetBookRequest{Id: uint64(m.ID), UserId: uint64(m.UserID)}
+	case models.GetBookRequest:
+		msg = &pb.GetBookRequest{Id: uint64(m.ID), UserId: uint64(m.UserID)}
+	case *models.GetBookHistoryRequest:
+		msg = &pb.GetBookHistoryRequest{Id: uint64(m.ID), UserId: uint64(m.UserID)}
+	case models.GetBookHistoryRequest:
+		msg = &pb.GetBookHistoryRequest{Id: uint64(m.ID), UserId: uint64(m.UserID)}
+	case *models.GetBookHistoryResponse:
+		msg = historyToPB(*m)
+	case models.GetBookHistoryResponse:
+		msg = historyToPB(m)
+	case *models.BatchBook:
+		msg = batchToPB(*m)
+	case models.BatchBook:
+		msg = batchToPB(m)
+	case *models.BatchBookResponse:
+		msg = batchResultsToPB(*m)
+	case models.BatchBookResponse:
+		msg = batchResultsToPB(m)
+	default:
+		return nil, fmt.Errorf("%w: no protobuf message for %T", errs.ErrInternal, v)
+	}
+
+	return proto.Marshal(msg)
+}
+
+func (Protobuf) Unmarshal(data []byte, v interface{}) error {
+	switch m := v.(type) {
+	case *models.KafkaEnvelope:
+		// requests and responses share the envelope field numbers
+		var msg pb.BookRequest
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*m = models.KafkaEnvelope{
+			SchemaVersion: int(msg.SchemaVersion),
+			MessageID:     msg.MessageId,
+			Method:        msg.Method,
+			Type:          msg.Type,
+			RelationID:    msg.RelationId,
+		}
+	case *models.KafkaBookRequest:
+		var msg pb.BookRequest
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*m = models.KafkaBookRequest{
+			SchemaVersion:  int(msg.SchemaVersion),
+			MessageID:      msg.MessageId,
+			Method:         msg.Method,
+			Type:           msg.Type,
+			RelationID:     msg.RelationId,
+			UserID:         uint(msg.UserId),
+			IdempotencyKey: msg.IdempotencyKey,
+			RequestID:      msg.RequestId,
+			Actor:          msg.Actor,
+			Reason:         msg.Reason,
+			SentAt:         timeFromPB(msg.SentAt),
+			Payload:        msg.Payload,
+		}
+	case *models.KafkaBookResponse:
+		var msg pb.BookResponse
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*m = models.KafkaBookResponse{
+			SchemaVersion: int(msg.SchemaVersion),
+			MessageID:     msg.MessageId,
+			Method:        msg.Method,
+			Type:          msg.Type,
+			RelationID:    msg.RelationId,
+			RequestID:     msg.RequestId,
+			SentAt:        timeFromPB(msg.SentAt),
+			Result:        msg.Result,
+		}
+		if msg.Error != nil {
+			m.Error = &models.KafkaError{
+				Code:    errs.Code(msg.Error.Code),
+				Message: msg.Error.Message,
+			}
+		}
+	case *models.GetAllBooksRequest:
+		var msg pb.GetAllBooksRequest
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+	case *models.PingRequest:
+		var msg pb.PingRequest
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+	case *models.GetAllBooksResponse:
+		var msg pb.GetAllBooksResponse
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		m.Users = make([]models.UserBooksResponse, len(msg.Users))
+		for i, u := range msg.Users {
+			m.Users[i] = models.UserBooksResponse{
+				Username:   u.Username,
+				TotalBooks: int(u.TotalBooks),
+				Books:      make([]models.BookResponse, len(u.Books)),
+			}
+			for j, b := range u.Books {
+				m.Users[i].Books[j] = models.BookResponse{
+					ID:     uint(b.Id),
+					Title:  b.Title,
+					Author: b.Author,
+					Price:  uint(b.Price),
+				}
+			}
+		}
+	case *models.GetUserBooksRequest:
+		var msg pb.GetUserBooksRequest
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*m = models.GetUserBooksRequest{
+			UserID: uint(msg.UserId),
+			Author: msg.Author,
+			Title:  msg.Title,
+			Limit:  int(msg.Limit),
+		}
+	case *models.GetUserBooksResponse:
+		var msg pb.GetUserBooksResponse
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		m.Books = make([]models.Book, len(msg.Books))
+		for i, b := range msg.Books {
+			m.Books[i] = bookFromPB(b)
+		}
+	case *models.Book:
+		var msg pb.Book
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*m = bookFromPB(&msg)
+	case *models.DeleteBook:
+		var msg pb.DeleteBook
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*m = models.DeleteBook{ID: uint(msg.Id), UserID: uint(msg.UserId), Version: uint(msg.Version)}
+	case *models.RevertBook:
+		var msg pb.RevertBook
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*m = models.RevertBook{
+			ID:       uint(msg.Id),
+			UserID:   uint(msg.UserId),
+			Revision: uint(msg.Revision),
+			Version:  uint(msg.Version),
+		}
+	case *models.GetBookRequest:
+		var msg pb.GetBookRequest
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*m = models.GetBookRequest{ID: uint(msg.Id), UserID: uint(msg.UserId)}
+	case *models.GetBookHistoryRequest:
+		var msg pb.GetBookHistoryRequest
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*m = models.GetBookHistoryRequest{ID: uint(msg.Id), UserID: uint(msg.UserId)}
+	case *models.GetBookHistoryResponse:
+		var msg pb.GetBookHistoryResponse
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		m.Revisions = make([]models.BookRevision, len(msg.Revisions))
+		for i, r := range msg.Revisions {
+			m.Revisions[i] = revisionFromPB(r)
+		}
+	case *models.BatchBook:
+		var msg pb.BatchBook
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*m = models.BatchBook{
+			UserID:     uint(msg.UserId),
+			Mode:       msg.Mode,
+			Operations: make([]models.BatchBookOperation, len(msg.Operations)),
+		}
+		for i, op := range msg.Operations {
+			m.Operations[i] = models.BatchBookOperation{Op: op.Op}
+			if op.Book != nil {
+				m.Operations[i].Book = bookFromPB(op.Book)
+			}
+		}
+	case *models.BatchBookResponse:
+		var msg pb.BatchBookResponse
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		*m = models.BatchBookResponse{
+			Committed: msg.Committed,
+			Results:   make([]models.BatchBookResult, len(msg.Results)),
+		}
+		for i, r := range msg.Results {
+			m.Results[i] = models.BatchBookResult{
+				Index: int(r.Index),
+				Op:    r.Op,
+				ID:    uint(r.Id),
+			}
+			if r.Error != nil {
+				m.Results[i].Error = &models.KafkaError{
+					Code:    errs.Code(r.Error.Code),
+					Message: r.Error.Message,
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("%w: no protobuf message for %T", errs.ErrInternal, v)
+	}
+
+	return nil
+}
+
+func requestToPB(r models.KafkaBookRequest) *pb.BookRequest {
+	return &pb.BookRequest{
+		SchemaVersion:  uint32(r.SchemaVersion),
+		MessageId:      r.MessageID,
+		Method:         r.Method,
+		Type:           r.Type,
+		RelationId:     r.RelationID,
+		UserId:         uint64(r.UserID),
+		IdempotencyKey: r.IdempotencyKey,
+		RequestId:      r.RequestID,
+		Actor:          r.Actor,
+		Reason:         r.Reason,
+		SentAt:         timeToPB(r.SentAt),
+		Payload:        r.Payload,
+	}
+}
+
+func responseToPB(r models.KafkaBookResponse) *pb.BookResponse {
+	msg := &pb.BookResponse{
+		SchemaVersion: uint32(r.SchemaVersion),
+		MessageId:     r.MessageID,
+		Method:        r.Method,
+		Type:          r.Type,
+		RelationId:    r.RelationID,
+		RequestId:     r.RequestID,
+		SentAt:        timeToPB(r.SentAt),
+		Result:        r.Result,
+	}
+	if r.Error != nil {
+		msg.Error = &pb.KafkaError{
+			Code:    string(r.Error.Code),
+			Message: r.Error.Message,
+		}
+	}
+	return msg
+}
+
+func getUserBooksToPB(r models.GetUserBooksRequest) *pb.GetUserBooksRequest {
+	return &pb.GetUserBooksRequest{
+		UserId: uint64(r.UserID),
+		Author: r.Author,
+		Title:  r.Title,
+		Limit:  int64(r.Limit),
+	}
+}
+
+func usersToPB(r models.GetAllBooksResponse) *pb.GetAllBooksResponse {
+	msg := &pb.GetAllBooksResponse{
+		Users: make([]*pb.UserBooks, len(r.Users)),
+	}
+	for i, u := range r.Users {
+		books := make([]*pb.Book, len(u.Books))
+		for j, b := range u.Books {
+			books[j] = &pb.Book{
+				Id:     uint64(b.ID),
+				Title:  b.Title,
+				Author: b.Author,
+				Price:  uint64(b.Price),
+			}
+		}
+		msg.Users[i] = &pb.UserBooks{
+			Username:   u.Username,
+			TotalBooks: int64(u.TotalBooks),
+			Books:      books,
+		}
+	}
+	return msg
+}
+
+func booksToPB(r models.GetUserBooksResponse) *pb.GetUserBooksResponse {
+	msg := &pb.GetUserBooksResponse{
+		Books: make([]*pb.Book, len(r.Books)),
+	}
+	for i, b := range r.Books {
+		msg.Books[i] = bookToPB(b)
+	}
+	return msg
+}
+
+func bookToPB(b models.Book) *pb.Book {
+	return &pb.Book{
+		Id:        uint64(b.ID),
+		Title:     b.Title,
+		Author:    b.Author,
+		Price:     uint64(b.Price),
+		UserId:    uint64(b.UserID),
+		Version:   uint64(b.Version),
+		CreatedAt: timeToPB(b.CreatedAt),
+		UpdatedAt: timeToPB(b.UpdatedAt),
+	}
+}
+
+func historyToPB(r models.GetBookHistoryResponse) *pb.GetBookHistoryResponse {
+	msg := &pb.GetBookHistoryResponse{
+		Revisions: make([]*pb.BookRevision, len(r.Revisions)),
+	}
+	for i, rev := range r.Revisions {
+		msg.Revisions[i] = &pb.BookRevision{
+			Id:        uint64(rev.ID),
+			BookId:    uint64(rev.BookID),
+			UserId:    uint64(rev.UserID),
+			Version:   uint64(rev.Version),
+			Operation: rev.Operation,
+			Title:     rev.Title,
+			Author:    rev.Author,
+			Price:     uint64(rev.Price),
+			Actor:     rev.Actor,
+			Reason:    rev.Reason,
+			CreatedAt: timeToPB(rev.CreatedAt),
+		}
+	}
+	return msg
+}
+
+func revertToPB(r models.RevertBook) *pb.RevertBook {
+	return &pb.RevertBook{
+		Id:       uint64(r.ID),
+		UserId:   uint64(r.UserID),
+		Revision: uint64(r.Revision),
+		Version:  uint64(r.Version),
+	}
+}
+
+func batchToPB(b models.BatchBook) *pb.BatchBook {
+	msg := &pb.BatchBook{
+		UserId:     uint64(b.UserID),
+		Mode:       b.Mode,
+		Operations: make([]*pb.BatchBookOperation, len(b.Operations)),
+	}
+	for i, op := range b.Operations {
+		msg.Operations[i] = &pb.BatchBookOperation{Op: op.Op, Book: bookToPB(op.Book)}
+	}
+	return msg
+}
+
+func batchResultsToPB(r models.BatchBookResponse) *pb.BatchBookResponse {
+	msg := &pb.BatchBookResponse{
+		Committed: r.Committed,
+		Results:   make([]*pb.BatchBookResult, len(r.Results)),
+	}
+	for i, res := range r.Results {
+		msg.Results[i] = &pb.BatchBookResult{
+			Index: int64(res.Index),
+			Op:    res.Op,
+			Id:    uint64(res.ID),
+		}
+		if res.Error != nil {
+			msg.Results[i].Error = &pb.KafkaError{
+				Code:    string(res.Error.Code),
+				Message: res.Error.Message,
+			}
+		}
+	}
+	return msg
+}
+
+func bookFromPB(b *pb.Book) models.Book {
+	return models.Book{
+		ID:        uint(b.Id),
+		Title:     b.Title,
+		Author:    b.Author,
+		Price:     uint(b.Price),
+		UserID:    uint(b.UserId),
+		Version:   uint(b.Version),
+		CreatedAt: timeFromPB(b.CreatedAt),
+		UpdatedAt: timeFromPB(b.UpdatedAt),
+	}
+}
+
+func revisionFromPB(r *pb.BookRevision) models.BookRevision {
+	return models.BookRevision{
+		ID:        uint(r.Id),
+		BookID:    uint(r.BookId),
+		UserID:    uint(r.UserId),
+		Version:   uint(r.Version),
+		Operation: r.Operation,
+		Title:     r.Title,
+		Author:    r.Author,
+		Price:     uint(r.Price),
+		Actor:     r.Actor,
+		Reason:    r.Reason,
+		CreatedAt: timeFromPB(r.CreatedAt),
+	}
+}
+
+// unset timestamps stay unset, AsTime would turn them into the Unix epoch
+func timeToPB(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timeFromPB(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
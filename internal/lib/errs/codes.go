@@ -0,0 +1,111 @@
+package errs
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code is a stable machine readable error identifier. Unlike error messages
+// codes never change, so they are safe to send between services and to
+// match on in clients
+type Code string
+
+const (
+	CodeInvalidParam         Code = "INVALID_PARAM"
+	CodeInvalidID            Code = "INVALID_ID"
+	CodeInvalidBody          Code = "INVALID_BODY"
+	CodeNotFound             Code = "NOT_FOUND"
+	CodeDBOperation          Code = "DB_OPERATION"
+	CodeInternal             Code = "INTERNAL"
+	CodeNotRegistred         Code = "NOT_REGISTERED"
+	CodeNotAuthorized        Code = "NOT_AUTHORIZED"
+	CodeInvalidToken         Code = "INVALID_TOKEN"
+	CodeTimeout              Code = "TIMEOUT"
+	CodeKafkaProducer        Code = "KAFKA_PRODUCER"
+	CodeKafkaConsumer        Code = "KAFKA_CONSUMER"
+	CodeInvalidMsg           Code = "INVALID_MESSAGE"
+	CodeKafkaAdmin           Code = "KAFKA_ADMIN"
+	CodeRolledBack           Code = "ROLLED_BACK"
+	CodeMigration            Code = "MIGRATION"
+	CodeKeyInUse             Code = "IDEMPOTENCY_KEY_IN_USE"
+	CodeKeyReused            Code = "IDEMPOTENCY_KEY_REUSED"
+	CodeRouteNotFound        Code = "ROUTE_NOT_FOUND"
+	CodeRateLimited          Code = "RATE_LIMITED"
+	CodePreconditionFailed   Code = "PRECONDITION_FAILED"
+	CodePreconditionRequired Code = "PRECONDITION_REQUIRED"
+)
+
+type entry struct {
+	code   Code
+	status int
+	title  string
+	err    error
+}
+
+// catalogue is the single place that maps codes to errors and HTTP
+// statuses. Codes are only ever added, never renamed
+var catalogue = []entry{
+	{CodeInvalidParam, http.StatusBadRequest, "Invalid parameter", ErrInvalidParam},
+	{CodeInvalidID, http.StatusBadRequest, "Invalid ID", ErrInvalidID},
+	{CodeInvalidBody, http.StatusBadRequest, "Invalid request body", ErrInvalidBody},
+	{CodeNotFound, http.StatusNotFound, "Not found", ErrNotFound},
+	{CodeDBOperation, http.StatusInternalServerError, "Database operation failed", ErrDBOperation},
+	{CodeInternal, http.StatusInternalServerError, "Internal server error", ErrInternal},
+	{CodeNotRegistred, http.StatusForbidden, "Incorrect password", ErrNotRegistred},
+	{CodeNotAuthorized, http.StatusUnauthorized, "Not authorized", ErrNotAuthorized},
+	{CodeInvalidToken, http.StatusUnauthorized, "Invalid token", ErrInvalidToken},
+	{CodeTimeout, http.StatusGatewayTimeout, "Request timed out", ErrTimeout},
+	{CodeKafkaProducer, http.StatusServiceUnavailable, "Request could not be queued", ErrKafkaProducer},
+	{CodeKafkaConsumer, http.StatusServiceUnavailable, "Reply could not be read", ErrKafkaConsumer},
+	{CodeInvalidMsg, http.StatusBadGateway, "Invalid reply from the worker", ErrInvalidMsg},
+	{CodeKafkaAdmin, http.StatusServiceUnavailable, "Kafka is not ready", ErrKafkaAdmin},
+	{CodeRolledBack, http.StatusFailedDependency, "Rolled back with the batch", ErrRolledBack},
+	{CodeMigration, http.StatusInternalServerError, "Database migration failed", ErrMigration},
+	{CodeKeyInUse, http.StatusConflict, "Idempotency key in use", ErrKeyInUse},
+	{CodeKeyReused, http.StatusUnprocessableEntity, "Idempotency key reused", ErrKeyReused},
+	{CodeRouteNotFound, http.StatusNotFound, "Route not found", ErrRouteNotFound},
+	{CodeRateLimited, http.StatusTooManyRequests, "Too many requests", ErrRateLimited},
+	{CodePreconditionFailed, http.StatusPreconditionFailed, "Precondition failed", ErrPreconditionFailed},
+	{CodePreconditionRequired, http.StatusPreconditionRequired, "Precondition required", ErrPreconditionRequired},
+}
+
+// CodeOf returns the code of the application error or of the first known
+// error in err's chain
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	for _, c := range catalogue {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return CodeInternal
+}
+
+// FromCode returns the error for code. Unknown codes, e.g. sent by a newer
+// service, are reported as ErrInternal
+func FromCode(code Code) error {
+	return lookup(code).err
+}
+
+// Status returns the HTTP status of code
+func Status(code Code) int {
+	return lookup(code).status
+}
+
+// Title returns the short summary of code, the same for every occurrence
+func Title(code Code) string {
+	return lookup(code).title
+}
+
+func lookup(code Code) entry {
+	for _, c := range catalogue {
+		if c.code == code {
+			return c
+		}
+	}
+	return lookup(CodeInternal)
+}
@@ -3,14 +3,87 @@ package errs
 import "errors"
 
 var (
-	ErrInvalidParam  = errors.New("invalid parameter value")
-	ErrInvalidID     = errors.New("invalid ID format")
-	ErrNotFound      = errors.New("record not found")
-	ErrDBOperation   = errors.New("database operation failed")
-	ErrInternal      = errors.New("internal server error")
-	ErrNotRegistred  = errors.New("you have not registered yet")
-	ErrNotAuthorized = errors.New("you are not authorized")
-	ErrTimeout       = errors.New("request time expired")
-	ErrKafkaProducer = errors.New("failed to produce message")
-	ErrKafkaConsumer = errors.New("failed to consume message")
+	ErrInvalidParam         = errors.New("invalid parameter value")
+	ErrInvalidID            = errors.New("invalid ID format")
+	ErrInvalidBody          = errors.New("invalid request body")
+	ErrNotFound             = errors.New("record not found")
+	ErrDBOperation          = errors.New("database operation failed")
+	ErrInternal             = errors.New("internal server error")
+	ErrNotRegistred         = errors.New("you have not registered yet")
+	ErrNotAuthorized        = errors.New("you are not authorized")
+	ErrInvalidToken         = errors.New("invalid token")
+	ErrTimeout              = errors.New("request time expired")
+	ErrKafkaProducer        = errors.New("failed to produce message")
+	ErrKafkaConsumer        = errors.New("failed to consume message")
+	ErrInvalidMsg           = errors.New("invalid message format")
+	ErrKafkaAdmin           = errors.New("kafka cluster is not ready")
+	ErrRolledBack           = errors.New("rolled back with the rest of the batch")
+	ErrMigration            = errors.New("database migration failed")
+	ErrKeyInUse             = errors.New("idempotency key is in use")
+	ErrKeyReused            = errors.New("idempotency key was used for another request")
+	ErrRouteNotFound        = errors.New("route not found")
+	ErrRateLimited          = errors.New("rate limit exceeded")
+	ErrPreconditionFailed   = errors.New("resource does not match the precondition")
+	ErrPreconditionRequired = errors.New("request must be conditional")
 )
+
+// FieldError is a rejected field of the request body
+type FieldError struct {
+	Field  string `json:"field"  example:"title"`
+	Reason string `json:"reason" example:"is required"`
+}
+
+// Error is an application error with a code of the catalogue. Detail is
+// the key of the case in the i18n catalogue, clients get its translation.
+// Fields and Current are shown as they are, Err is the cause and only gets
+// logged. errors.Is matches it against the sentinel error of its code
+type Error struct {
+	Code   Code
+	Detail string
+	Fields []FieldError
+	// Current is the state of the resource the request conflicted with
+	Current any
+	Err     error
+}
+
+func New(code Code, detail string) *Error {
+	return &Error{Code: code, Detail: detail}
+}
+
+// Wrap keeps err as the cause of the new error
+func Wrap(code Code, detail string, err error) *Error {
+	return &Error{Code: code, Detail: detail, Err: err}
+}
+
+func (e *Error) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = FromCode(e.Code).Error()
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{FromCode(e.Code)}
+	}
+	return []error{FromCode(e.Code), e.Err}
+}
+
+// From returns the application error in err's chain or builds one from the
+// first known sentinel. Causes of server side errors are not exposed
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	code := CodeOf(err)
+	if Status(code) >= 500 {
+		return Wrap(code, "", err)
+	}
+	return New(code, err.Error())
+}
@@ -0,0 +1,230 @@
+package i18n
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed locales/*.yaml
+var files embed.FS
+
+// catalogue is one locales/<locale>.yaml file
+type catalogue struct {
+	Errors     map[errs.Code]errorText `yaml:"errors"`
+	Messages   map[string]string       `yaml:"messages"`
+	Validation map[string]string       `yaml:"validation"`
+}
+
+// errorText translates an error code. Detail describes every error of the
+// code, Messages the single cases by the key the error was made with
+type errorText struct {
+	Title    string            `yaml:"title"`
+	Detail   string            `yaml:"detail"`
+	Messages map[string]string `yaml:"messages"`
+}
+
+var (
+	catalogues = mustLoad()
+
+	mu       sync.RWMutex
+	fallback = "ru"
+	matcher  = newMatcher(fallback)
+)
+
+type ctxKey struct{}
+
+func mustLoad() map[string]catalogue {
+	entries, err := fs.Glob(files, "locales/*.yaml")
+	if err != nil {
+		panic(err)
+	}
+
+	loaded := make(map[string]catalogue, len(entries))
+	for _, name := range entries {
+		data, err := files.ReadFile(name)
+		if err != nil {
+			panic(err)
+		}
+
+		var c catalogue
+		if err := yaml.Unmarshal(data, &c); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", name, err))
+		}
+		loaded[strings.TrimSuffix(path.Base(name), ".yaml")] = c
+	}
+	return loaded
+}
+
+// Locales returns the supported locales
+func Locales() []string {
+	locales := make([]string, 0, len(catalogues))
+	for l := range catalogues {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+func Supported(locale string) bool {
+	_, ok := catalogues[locale]
+	return ok
+}
+
+// SetFallback sets the locale used when the client accepts none of the
+// supported ones and for messages missing in a catalogue
+func SetFallback(locale string) error {
+	if !Supported(locale) {
+		return fmt.Errorf("unsupported locale %q, expected one of %s", locale, strings.Join(Locales(), ", "))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	fallback = locale
+	matcher = newMatcher(locale)
+	return nil
+}
+
+func Fallback() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return fallback
+}
+
+// the matcher answers with its first tag when nothing matches
+func newMatcher(fallback string) language.Matcher {
+	tags := []language.Tag{language.Make(fallback)}
+	for _, l := range Locales() {
+		if l != fallback {
+			tags = append(tags, language.Make(l))
+		}
+	}
+	return language.NewMatcher(tags)
+}
+
+// Match picks the supported locale that suits an Accept-Language header best
+func Match(acceptLanguage string) string {
+	mu.RLock()
+	m := matcher
+	mu.RUnlock()
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Fallback()
+	}
+
+	// low confidence means an unrelated language, e.g. en for fr
+	tag, _, conf := m.Match(tags...)
+	base, _ := tag.Base()
+	if conf < language.High || !Supported(base.String()) {
+		return Fallback()
+	}
+	return base.String()
+}
+
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, locale)
+}
+
+// FromContext returns the locale of the request, the fallback one if none
+// was negotiated
+func FromContext(ctx context.Context) string {
+	if l, ok := ctx.Value(ctxKey{}).(string); ok {
+		return l
+	}
+	return Fallback()
+}
+
+// Title returns the title of an error code
+func Title(locale string, code errs.Code) string {
+	if t, ok := lookup(locale, func(c catalogue) (string, bool) {
+		t := c.Errors[code].Title
+		return t, t != ""
+	}); ok {
+		return t
+	}
+	return errs.Title(code)
+}
+
+// Detail returns the text of the case key of code, or the general detail
+// of code when the key has no translation. Keys are never shown as they
+// are, an error made from a wrapped cause only gets the general detail
+func Detail(locale string, code errs.Code, key string) string {
+	if key != "" {
+		if t, ok := lookup(locale, func(c catalogue) (string, bool) {
+			t, ok := c.Errors[code].Messages[key]
+			return t, ok
+		}); ok {
+			return t
+		}
+	}
+
+	if t, ok := lookup(locale, func(c catalogue) (string, bool) {
+		t := c.Errors[code].Detail
+		return t, t != ""
+	}); ok {
+		return t
+	}
+	return errs.Title(code)
+}
+
+// Message returns the success message key in locale, args are formatted
+// into it. A key missing in every catalogue is returned as it is
+func Message(locale, key string, args ...any) string {
+	msg, ok := lookup(locale, func(c catalogue) (string, bool) {
+		t, ok := c.Messages[key]
+		return t, ok
+	})
+	if !ok {
+		msg = key
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Validation describes why a field failed the validator tag
+func Validation(locale, tag, param string) string {
+	reason, ok := lookup(locale, func(c catalogue) (string, bool) {
+		t, ok := c.Validation[tag]
+		return t, ok
+	})
+	if !ok {
+		reason, _ = lookup(locale, func(c catalogue) (string, bool) {
+			t, ok := c.Validation["default"]
+			return t, ok
+		})
+		param = tag
+	}
+
+	if strings.Contains(reason, "%s") {
+		return fmt.Sprintf(reason, param)
+	}
+	return reason
+}
+
+// lookup tries the catalogue of locale, then the one of the fallback locale
+func lookup(locale string, get func(catalogue) (string, bool)) (string, bool) {
+	if c, ok := catalogues[locale]; ok {
+		if t, ok := get(c); ok {
+			return t, true
+		}
+	}
+	if c, ok := catalogues[Fallback()]; ok {
+		if t, ok := get(c); ok {
+			return t, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,135 @@
+package i18n
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"empty", "", "ru"},
+		{"exact", "en", "en"},
+		{"region", "en-GB", "en"},
+		{"quality order", "ru;q=0.5, en;q=0.9", "en"},
+		{"first supported", "fr, en;q=0.8", "en"},
+		{"unrelated", "fr", "ru"},
+		{"wildcard", "*", "ru"},
+		{"malformed", "en;q=abc", "ru"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Match(tt.header); got != tt.want {
+				t.Errorf("Match(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchFallback(t *testing.T) {
+	if err := SetFallback("en"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetFallback("ru") })
+
+	if got := Match("fr"); got != "en" {
+		t.Errorf("Match(fr) = %q, want the fallback en", got)
+	}
+	if got := Match("ru"); got != "ru" {
+		t.Errorf("Match(ru) = %q, want ru", got)
+	}
+	if err := SetFallback("fr"); err == nil {
+		t.Error("SetFallback(fr) succeeded, want an error")
+	}
+}
+
+func TestDetail(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		code   errs.Code
+		key    string
+		want   string
+	}{
+		{"case", "en", errs.CodeNotAuthorized, "token_expired", catalogues["en"].Errors[errs.CodeNotAuthorized].Messages["token_expired"]},
+		{"no key", "en", errs.CodeNotAuthorized, "", catalogues["en"].Errors[errs.CodeNotAuthorized].Detail},
+		{"raw cause", "en", errs.CodeNotFound, "record not found", catalogues["en"].Errors[errs.CodeNotFound].Detail},
+		{"key of another code", "ru", errs.CodeNotFound, "token_expired", catalogues["ru"].Errors[errs.CodeNotFound].Detail},
+		{"unsupported locale", "fr", errs.CodeNotAuthorized, "token_expired", catalogues["ru"].Errors[errs.CodeNotAuthorized].Messages["token_expired"]},
+		{"unknown code", "en", errs.Code("NO_SUCH_CODE"), "", errs.Title(errs.Code("NO_SUCH_CODE"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detail(tt.locale, tt.code, tt.key); got != tt.want {
+				t.Errorf("Detail(%s, %s, %q) = %q, want %q", tt.locale, tt.code, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		key    string
+		args   []any
+		want   string
+	}{
+		{"plain", "en", "user_created", nil, "User created"},
+		{"args", "en", "token_issued", []any{"abc"}, "Your token: abc"},
+		{"unsupported locale", "fr", "user_created", nil, catalogues["ru"].Messages["user_created"]},
+		{"unknown key", "en", "no_such_message", nil, "no_such_message"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Message(tt.locale, tt.key, tt.args...); got != tt.want {
+				t.Errorf("Message(%s, %s) = %q, want %q", tt.locale, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+// every locale has to translate the same codes, cases and messages, and
+// every code a title and a detail
+func TestCatalogues(t *testing.T) {
+	codes := []errs.Code{
+		errs.CodeInvalidParam, errs.CodeInvalidID, errs.CodeInvalidBody, errs.CodeNotFound,
+		errs.CodeDBOperation, errs.CodeInternal, errs.CodeNotRegistred, errs.CodeNotAuthorized,
+		errs.CodeInvalidToken, errs.CodeTimeout, errs.CodeKafkaProducer, errs.CodeKafkaConsumer,
+		errs.CodeInvalidMsg, errs.CodeKafkaAdmin, errs.CodeRolledBack, errs.CodeMigration,
+		errs.CodeKeyInUse, errs.CodeKeyReused, errs.CodeRouteNotFound, errs.CodeRateLimited,
+		errs.CodePreconditionFailed, errs.CodePreconditionRequired,
+	}
+
+	ref := catalogues[Fallback()]
+	for _, locale := range Locales() {
+		c := catalogues[locale]
+
+		for _, code := range codes {
+			e := c.Errors[code]
+			if e.Title == "" || e.Detail == "" {
+				t.Errorf("%s: %s has no title or detail", locale, code)
+			}
+			if got, want := slices.Sorted(maps.Keys(e.Messages)), slices.Sorted(maps.Keys(ref.Errors[code].Messages)); !slices.Equal(got, want) {
+				t.Errorf("%s: cases of %s = %v, want %v", locale, code, got, want)
+			}
+		}
+		if got, want := slices.Sorted(maps.Keys(c.Errors)), slices.Sorted(maps.Keys(ref.Errors)); !slices.Equal(got, want) {
+			t.Errorf("%s: codes = %v, want %v", locale, got, want)
+		}
+		if got, want := slices.Sorted(maps.Keys(c.Messages)), slices.Sorted(maps.Keys(ref.Messages)); !slices.Equal(got, want) {
+			t.Errorf("%s: messages = %v, want %v", locale, got, want)
+		}
+		if got, want := slices.Sorted(maps.Keys(c.Validation)), slices.Sorted(maps.Keys(ref.Validation)); !slices.Equal(got, want) {
+			t.Errorf("%s: validation = %v, want %v", locale, got, want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package reqctx
+
+import "context"
+
+type ctxKey int
+
+const (
+	idempotencyKey ctxKey = iota
+	respondAsync
+	requestID
+	actor
+	changeReason
+)
+
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey, key)
+}
+
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey).(string)
+	return key
+}
+
+// WithRespondAsync asks the service to return a job instead of waiting for
+// the worker reply
+func WithRespondAsync(ctx context.Context) context.Context {
+	return context.WithValue(ctx, respondAsync, true)
+}
+
+func RespondAsync(ctx context.Context) bool {
+	async, _ := ctx.Value(respondAsync).(bool)
+	return async
+}
+
+// WithRequestID stores the ID that ties together the logs of one request
+// in the API and in the worker
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestID, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestID).(string)
+	return id
+}
+
+// WithActor stores who makes the request, e.g. "user:5". Book revisions
+// record it as the author of the change
+func WithActor(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, actor, name)
+}
+
+func Actor(ctx context.Context) string {
+	name, _ := ctx.Value(actor).(string)
+	return name
+}
+
+// WithChangeReason stores why the client changes books, book revisions
+// record it next to the change
+func WithChangeReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, changeReason, reason)
+}
+
+func ChangeReason(ctx context.Context) string {
+	reason, _ := ctx.Value(changeReason).(string)
+	return reason
+}
@@ -0,0 +1,76 @@
+package schema
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bytes"
+	"embed"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Names of the embedded JSON Schemas. Schemas never forbid additional
+// properties, so a message from a newer producer still validates
+const (
+	Request        = "request.json"
+	Response       = "response.json"
+	GetAllBooks    = "get_all_books.json"
+	GetUserBooks   = "get_user_books.json"
+	GetBook        = "get_book.json"
+	GetBookHistory = "get_book_history.json"
+	Book           = "book.json"
+	DeleteBook     = "delete_book.json"
+	RevertBook     = "revert_book.json"
+	BatchBook      = "batch_book.json"
+	Ping           = "ping.json"
+)
+
+// baseURL only names the schemas for the compiler, nothing is downloaded
+const baseURL = "https://bookstore-api.local/schemas/"
+
+//go:embed schemas/*.json
+var files embed.FS
+
+var compiled = mustCompile(Request, Response, GetAllBooks, GetUserBooks, GetBook, GetBookHistory, Book, DeleteBook, RevertBook, BatchBook, Ping)
+
+func mustCompile(names ...string) map[string]*jsonschema.Schema {
+	c := jsonschema.NewCompiler()
+	for _, name := range names {
+		raw, err := files.ReadFile("schemas/" + name)
+		if err != nil {
+			panic(err)
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+		if err != nil {
+			panic(fmt.Sprintf("schema %s: %v", name, err))
+		}
+		if err := c.AddResource(baseURL+name, doc); err != nil {
+			panic(fmt.Sprintf("schema %s: %v", name, err))
+		}
+	}
+
+	result := make(map[string]*jsonschema.Schema, len(names))
+	for _, name := range names {
+		result[name] = c.MustCompile(baseURL + name)
+	}
+	return result
+}
+
+// Validate checks a JSON document against the named schema
+func Validate(name string, raw []byte) error {
+	sch, ok := compiled[name]
+	if !ok {
+		return fmt.Errorf("%w: unknown schema %s", errs.ErrInvalidMsg, name)
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrInvalidMsg, err)
+	}
+
+	if err := sch.Validate(doc); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrInvalidMsg, err)
+	}
+
+	return nil
+}
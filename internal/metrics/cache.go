@@ -0,0 +1,18 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	CacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bookstore_cache_lookups_total",
+		Help: "Cache lookups by cache and result (hit, miss or error).",
+	}, []string{"cache", "result"})
+
+	CacheInvalidations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bookstore_cache_invalidations_total",
+		Help: "Cache invalidations by cache.",
+	}, []string{"cache"})
+)
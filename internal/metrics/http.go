@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// HTTPRequests and HTTPDuration are labeled with the route template,
+	// e.g. /api/books/:id, so the number of series stays bounded
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bookstore_http_requests_total",
+		Help: "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bookstore_http_request_duration_seconds",
+		Help:    "Time to serve an HTTP request by route, method and status code.",
+		Buckets: latencyBuckets,
+	}, []string{"route", "method", "status"})
+
+	HTTPInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "bookstore_http_requests_in_flight",
+		Help: "HTTP requests currently being served.",
+	})
+)
+
+var RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "bookstore_http_rate_limited_total",
+	Help: "Requests rejected by the rate limiter by route group.",
+}, []string{"group"})
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Buckets from 5ms to ~40s, the API gives up on a reply after 30s
+var latencyBuckets = prometheus.ExponentialBuckets(0.005, 2, 14)
+
+var (
+	// RoundTrip is measured by the API from sending a request until its
+	// reply arrives or the wait times out
+	RoundTrip = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bookstore_kafka_round_trip_seconds",
+		Help:    "Time from producing a book request until its reply is received.",
+		Buckets: latencyBuckets,
+	}, []string{"method", "outcome"})
+
+	// QueueWait is how long a request waited in Kafka and in the worker
+	// queue before it started executing
+	QueueWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bookstore_kafka_queue_wait_seconds",
+		Help:    "Time from producing a book request until the worker starts it.",
+		Buckets: latencyBuckets,
+	}, []string{"method"})
+
+	// Processing covers the database work of one request in the worker
+	Processing = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bookstore_kafka_processing_seconds",
+		Help:    "Time the worker spends executing a book request.",
+		Buckets: latencyBuckets,
+	}, []string{"method"})
+
+	Produce = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bookstore_kafka_produce_seconds",
+		Help:    "Time until the broker acknowledges a produced message.",
+		Buckets: latencyBuckets,
+	}, []string{"topic"})
+
+	Errors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bookstore_kafka_request_errors_total",
+		Help: "Failed book requests by method and error code.",
+	}, []string{"component", "method", "code"})
+
+	// PendingRequests is the size of the responses map of the API, the
+	// requests that wait for a reply
+	PendingRequests = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "bookstore_kafka_pending_requests",
+		Help: "Requests sent by the API that are still waiting for a reply.",
+	})
+
+	ConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bookstore_kafka_consumer_lag",
+		Help: "Messages between the high watermark and the consumer position.",
+	}, []string{"topic", "partition"})
+)
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"bookstore-api/internal/lib/sl"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// WatchConsumerLag updates ConsumerLag for the partitions assigned to c
+// every interval, using the watermark offsets reported by the broker
+func WatchConsumerLag(c *kafka.Consumer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		assigned, err := c.Assignment()
+		if err != nil {
+			slog.Error("metrics.WatchConsumerLag", sl.Error(err))
+			continue
+		}
+
+		positions, err := c.Position(assigned)
+		if err != nil {
+			slog.Error("metrics.WatchConsumerLag", sl.Error(err))
+			continue
+		}
+
+		// nothing was read from a partition yet, fall back to the committed offset
+		committed, err := c.Committed(assigned, 5000)
+		if err != nil {
+			slog.Error("metrics.WatchConsumerLag", sl.Error(err))
+			continue
+		}
+
+		ConsumerLag.Reset()
+		for i, tp := range positions {
+			low, high, err := c.QueryWatermarkOffsets(*tp.Topic, tp.Partition, 5000)
+			if err != nil {
+				slog.Error("metrics.WatchConsumerLag", sl.Error(err))
+				continue
+			}
+
+			offset := int64(tp.Offset)
+			if offset < 0 && i < len(committed) {
+				offset = int64(committed[i].Offset)
+			}
+			if offset < 0 {
+				offset = low
+			}
+			lag := high - offset
+
+			ConsumerLag.
+				WithLabelValues(*tp.Topic, strconv.Itoa(int(tp.Partition))).
+				Set(float64(lag))
+		}
+	}
+}
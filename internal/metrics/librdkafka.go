@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StatsInterval is the statistics.interval.ms set on producers and
+// consumers, a scrape sees values at most this old
+const StatsInterval = 15000
+
+// librdkafkaStats is the part of the librdkafka statistics JSON that is
+// exported, see STATISTICS.md of librdkafka
+type librdkafkaStats struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	MsgCnt  int64  `json:"msg_cnt"`
+	MsgSize int64  `json:"msg_size"`
+	Tx      int64  `json:"tx"`
+	Rx      int64  `json:"rx"`
+	TxMsgs  int64  `json:"txmsgs"`
+	RxMsgs  int64  `json:"rxmsgs"`
+	ReplyQ  int64  `json:"replyq"`
+
+	Brokers map[string]struct {
+		NodeID int32  `json:"nodeid"`
+		State  string `json:"state"`
+		TxErrs int64  `json:"txerrs"`
+		RxErrs int64  `json:"rxerrs"`
+		Rtt    struct {
+			Avg int64 `json:"avg"`
+		} `json:"rtt"`
+	} `json:"brokers"`
+
+	Cgrp *struct {
+		RebalanceCnt int64 `json:"rebalance_cnt"`
+	} `json:"cgrp"`
+}
+
+var (
+	kafkaClientLabels = []string{"type", "client"}
+	kafkaBrokerLabels = []string{"type", "client", "broker"}
+
+	kafkaQueuedMsgs  = prometheus.NewDesc("bookstore_kafka_client_queued_messages", "Messages waiting in the librdkafka queues.", kafkaClientLabels, nil)
+	kafkaQueuedBytes = prometheus.NewDesc("bookstore_kafka_client_queued_bytes", "Size of the messages waiting in the librdkafka queues.", kafkaClientLabels, nil)
+	kafkaReplyQ      = prometheus.NewDesc("bookstore_kafka_client_reply_queue_events", "Events waiting to be polled by the application.", kafkaClientLabels, nil)
+	kafkaTxRequests  = prometheus.NewDesc("bookstore_kafka_client_requests_sent_total", "Requests sent to the brokers.", kafkaClientLabels, nil)
+	kafkaRxResponses = prometheus.NewDesc("bookstore_kafka_client_responses_received_total", "Responses received from the brokers.", kafkaClientLabels, nil)
+	kafkaTxMsgs      = prometheus.NewDesc("bookstore_kafka_client_messages_sent_total", "Messages produced to the brokers.", kafkaClientLabels, nil)
+	kafkaRxMsgs      = prometheus.NewDesc("bookstore_kafka_client_messages_received_total", "Messages consumed from the brokers.", kafkaClientLabels, nil)
+	kafkaRebalances  = prometheus.NewDesc("bookstore_kafka_consumer_rebalances_total", "Consumer group rebalances.", kafkaClientLabels, nil)
+	kafkaBrokerUp    = prometheus.NewDesc("bookstore_kafka_broker_up", "Whether the connection to the broker is up.", kafkaBrokerLabels, nil)
+	kafkaBrokerRtt   = prometheus.NewDesc("bookstore_kafka_broker_rtt_seconds", "Average broker round trip time over the last statistics interval.", kafkaBrokerLabels, nil)
+	kafkaBrokerErrs  = prometheus.NewDesc("bookstore_kafka_broker_errors_total", "Failed requests to the broker by direction.", append(kafkaBrokerLabels, "direction"), nil)
+)
+
+// kafkaStats keeps the last statistics of every client and exports them
+// on scrape
+type kafkaStats struct {
+	mu      sync.Mutex
+	clients map[string]librdkafkaStats
+}
+
+var librdkafka = &kafkaStats{clients: make(map[string]librdkafkaStats)}
+
+func init() {
+	prometheus.MustRegister(librdkafka)
+}
+
+// ObserveKafkaStats records a statistics event of a producer or consumer.
+// Events that don't parse are dropped, the next one replaces them anyway
+func ObserveKafkaStats(e *kafka.Stats) {
+	var s librdkafkaStats
+	if err := json.Unmarshal([]byte(e.String()), &s); err != nil || s.Name == "" {
+		return
+	}
+
+	librdkafka.mu.Lock()
+	defer librdkafka.mu.Unlock()
+	librdkafka.clients[s.Name] = s
+}
+
+func (k *kafkaStats) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		kafkaQueuedMsgs, kafkaQueuedBytes, kafkaReplyQ,
+		kafkaTxRequests, kafkaRxResponses, kafkaTxMsgs, kafkaRxMsgs,
+		kafkaRebalances, kafkaBrokerUp, kafkaBrokerRtt, kafkaBrokerErrs,
+	} {
+		ch <- d
+	}
+}
+
+func (k *kafkaStats) Collect(ch chan<- prometheus.Metric) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for _, s := range k.clients {
+		gauge := func(d *prometheus.Desc, v int64) {
+			ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, float64(v), s.Type, s.Name)
+		}
+		counter := func(d *prometheus.Desc, v int64) {
+			ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, float64(v), s.Type, s.Name)
+		}
+
+		gauge(kafkaQueuedMsgs, s.MsgCnt)
+		gauge(kafkaQueuedBytes, s.MsgSize)
+		gauge(kafkaReplyQ, s.ReplyQ)
+		counter(kafkaTxRequests, s.Tx)
+		counter(kafkaRxResponses, s.Rx)
+		counter(kafkaTxMsgs, s.TxMsgs)
+		counter(kafkaRxMsgs, s.RxMsgs)
+		if s.Cgrp != nil {
+			counter(kafkaRebalances, s.Cgrp.RebalanceCnt)
+		}
+
+		for name, b := range s.Brokers {
+			// bootstrap and coordinator entries duplicate the real brokers
+			if b.NodeID < 0 {
+				continue
+			}
+
+			up := 0.0
+			if b.State == "UP" {
+				up = 1
+			}
+			ch <- prometheus.MustNewConstMetric(kafkaBrokerUp, prometheus.GaugeValue, up, s.Type, s.Name, name)
+			// librdkafka reports microseconds
+			ch <- prometheus.MustNewConstMetric(kafkaBrokerRtt, prometheus.GaugeValue, float64(b.Rtt.Avg)/1e6, s.Type, s.Name, name)
+			ch <- prometheus.MustNewConstMetric(kafkaBrokerErrs, prometheus.CounterValue, float64(b.TxErrs), s.Type, s.Name, name, "tx")
+			ch <- prometheus.MustNewConstMetric(kafkaBrokerErrs, prometheus.CounterValue, float64(b.RxErrs), s.Type, s.Name, name, "rx")
+		}
+	}
+}
@@ -1,10 +1,11 @@
 package middleware
 
 import (
-	"bookstore-api/internal/models"
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/reqctx"
 	"bookstore-api/internal/utils"
-	"log/slog"
-	"net/http"
+	"crypto/subtle"
+	"fmt"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -15,49 +16,38 @@ import (
 // @Description Protects endpoints through JWT token
 // @Security ApiKeyAuth
 // @Param Authorization header string true "JWT Token" default(Bearer <token>)
-// @Failure 400 {object} models.ErrorResponse "Invalid token"
-// @Failure 401 {object} models.ErrorResponse "Required authorization"
-func JWTAuth() gin.HandlerFunc {
+func JWTAuth(secret []byte) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error: "Authorization field empty",
-			})
+			Abort(c, errs.New(errs.CodeNotAuthorized, "authorization_header_empty"))
 			return
 		}
 
-		slog.Debug("header of authorization", "authHeader", authHeader)
-
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error: "Authorization header format must be 'Bearer <token>'",
-			})
+			Abort(c, errs.New(errs.CodeNotAuthorized, "authorization_header_format"))
 			return
 		}
 
-		slog.Debug("parts of header", "parts", parts)
-
-		token, err := utils.ParseToken(parts[1])
+		token, err := utils.ParseToken(secret, parts[1])
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{
-				Error: "Invalid token",
-			})
+			Abort(c, errs.New(errs.CodeInvalidToken, ""))
 			return
 		}
 
 		if !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error: "Token is expired or invalid",
-			})
+			Abort(c, errs.New(errs.CodeNotAuthorized, "token_expired"))
 			return
 		}
 
 		claims := token.Claims.(jwt.MapClaims)
 
 		c.Set("userID", claims["user_id"])
+		// the user is the author of the books they change
+		ctx := reqctx.WithActor(c.Request.Context(), fmt.Sprintf("user:%d", contextUserID(c)))
+		c.Request = c.Request.WithContext(ctx)
 		c.Next()
 	}
 }
@@ -65,20 +55,19 @@ func JWTAuth() gin.HandlerFunc {
 // @Summary Basic Authentication for Admin
 // @Description Protects endpoints through Basic Auth
 // @Security BasicAuth
-// @Failure 401 {object} models.ErrorResponse "Invalid credentials"
-func AdminAuth() gin.HandlerFunc {
-	auth := gin.BasicAuth(gin.Accounts{
-		"SuperUser": "qwerty12345",
-	})
+func AdminAuth(user, password string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		auth(c)
-		if c.IsAborted() {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error: "check username or password",
-			})
+		u, p, ok := c.Request.BasicAuth()
+		// both are compared, so the time does not tell which one is wrong
+		userOK := subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1
+		passOK := subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1
+		if !ok || !userOK || !passOK {
+			c.Header("WWW-Authenticate", `Basic realm="Authorization Required"`)
+			Abort(c, errs.New(errs.CodeNotAuthorized, "credentials_invalid"))
 			return
 		}
 
+		c.Set(gin.AuthUserKey, u)
 		c.Next()
 	}
 }
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/reqctx"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ChangeReasonHeader = "X-Change-Reason"
+
+	maxChangeReasonLength = 255
+)
+
+// ChangeReason passes the X-Change-Reason of write requests on to the
+// book history
+func ChangeReason() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reason := strings.TrimSpace(c.GetHeader(ChangeReasonHeader))
+		if reason == "" || !isWriteMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if utf8.RuneCountInString(reason) > maxChangeReasonLength {
+			Abort(c, errs.New(errs.CodeInvalidParam, "change_reason_too_long"))
+			return
+		}
+
+		c.Request = c.Request.WithContext(reqctx.WithChangeReason(c.Request.Context(), reason))
+		c.Next()
+	}
+}
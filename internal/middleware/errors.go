@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/i18n"
+	"bookstore-api/internal/lib/reqctx"
+	"bookstore-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+
+	problemTypePrefix = "urn:bookstore:error:"
+)
+
+// Errors renders the last error added with c.Error as problem details
+// (RFC 7807), unless a response was written already. Handlers and the
+// middlewares after it only report errors, the status comes from the code
+// catalogue of errs
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		renderError(c)
+	}
+}
+
+// Recovery answers panics with an INTERNAL problem
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, _ any) {
+		c.Error(errs.ErrInternal)
+		renderError(c)
+		c.Abort()
+	})
+}
+
+// Abort stops the chain with err, Errors renders it
+func Abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// NoRoute reports unknown paths in the same format as the other errors
+func NoRoute(c *gin.Context) {
+	c.Error(errs.New(errs.CodeRouteNotFound, ""))
+}
+
+func renderError(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	c.Header("Content-Type", ProblemContentType)
+	p := problem(c, c.Errors.Last().Err)
+	c.JSON(p.Status, p)
+}
+
+// problem builds the problem details of err for the current request
+func problem(c *gin.Context, err error) models.Problem {
+	e := errs.From(err)
+	locale := i18n.FromContext(c.Request.Context())
+
+	return models.Problem{
+		Type:      problemTypePrefix + string(e.Code),
+		Title:     i18n.Title(locale, e.Code),
+		Status:    errs.Status(e.Code),
+		Detail:    i18n.Detail(locale, e.Code, e.Detail),
+		Instance:  c.Request.URL.Path,
+		Code:      e.Code,
+		RequestID: reqctx.RequestID(c.Request.Context()),
+		Errors:    e.Fields,
+		Current:   e.Current,
+	}
+}
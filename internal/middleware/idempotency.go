@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"bookstore-api/api/repository"
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/lib/reqctx"
+	"bookstore-api/internal/lib/sl"
+	"bookstore-api/internal/models"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// replayedHeaders describe the stored response and are sent again with it.
+// The others, e.g. the request ID or the rate limit, belong to the request
+var replayedHeaders = []string{
+	"Content-Type",
+	"Content-Language",
+	"Cache-Control",
+	"ETag",
+	"Last-Modified",
+	"Location",
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency keeps keys for ttl. A request still in progress after lease
+// is considered lost and a retry may take its key, so lease must outlast
+// the longest request
+//
+// @Summary Idempotent writes
+// @Description Replays the stored response for a repeated Idempotency-Key
+// @Param Idempotency-Key header string false "Unique key of the write request"
+// @Failure 409 {object} models.Problem "Request with this key is still in progress"
+// @Failure 422 {object} models.Problem "Key was used with another request"
+func Idempotency(repo repository.IdempotencyRepository, ttl, lease time.Duration) gin.HandlerFunc {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for now := range ticker.C {
+			if err := repo.DeleteExpired(now, ttl); err != nil {
+				slog.Error("middleware.Idempotency cleanup", sl.Error(err))
+			}
+		}
+	}()
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !isWriteMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if len(key) > 255 {
+			Abort(c, errs.New(errs.CodeInvalidParam, "idempotency_key_too_long"))
+			return
+		}
+
+		userID := contextUserID(c)
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			Abort(c, errs.Wrap(errs.CodeInvalidBody, "", err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		// the query picks what some writes do, e.g. the revision to revert to
+		target := c.Request.URL.Path
+		if c.Request.URL.RawQuery != "" {
+			target += "?" + c.Request.URL.RawQuery
+		}
+		hash.Write([]byte(c.Request.Method + " " + target + "\n"))
+		hash.Write(body)
+		// the same change under another precondition is another request
+		if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+			hash.Write([]byte("\nIf-Match: " + ifMatch))
+		}
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		rec, reserved, err := repo.Reserve(models.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: requestHash,
+			ExpiresAt:   time.Now().Add(ttl),
+		}, lease)
+		if err != nil && !errors.Is(err, errs.ErrNotFound) {
+			Abort(c, err)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case errors.Is(err, errs.ErrNotFound):
+				Abort(c, errs.New(errs.CodeKeyInUse, ""))
+			case rec.RequestHash != requestHash:
+				Abort(c, errs.New(errs.CodeKeyReused, ""))
+			case !rec.Completed:
+				Abort(c, errs.New(errs.CodeKeyInUse, ""))
+			default:
+				slog.InfoContext(c.Request.Context(), "replay idempotent response", "key", key, "userID", userID)
+
+				replay(c, rec)
+			}
+			return
+		}
+
+		c.Request = c.Request.WithContext(reqctx.WithIdempotencyKey(c.Request.Context(), key))
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+		// the response has to be written to be stored
+		renderError(c)
+
+		// server side failures are not remembered, the client may retry them
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := repo.Release(userID, key); err != nil {
+				slog.ErrorContext(c.Request.Context(), "middleware.Idempotency release", sl.Error(err))
+			}
+			return
+		}
+
+		err = repo.Complete(
+			userID,
+			key,
+			recorder.Status(),
+			responseHeaders(recorder.Header()),
+			recorder.body.Bytes(),
+		)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "middleware.Idempotency complete", sl.Error(err))
+		}
+	}
+}
+
+// responseHeaders picks the replayedHeaders out of the response
+func responseHeaders(h http.Header) http.Header {
+	stored := make(http.Header)
+	for _, name := range replayedHeaders {
+		if v := h.Values(name); len(v) > 0 {
+			stored[http.CanonicalHeaderKey(name)] = v
+		}
+	}
+	return stored
+}
+
+// replay answers with the stored response. Its headers replace the ones of
+// the same name set for this request, e.g. Content-Language
+func replay(c *gin.Context, rec models.IdempotencyKey) {
+	for name, values := range rec.Headers {
+		c.Writer.Header()[name] = values
+	}
+	c.Header("Idempotent-Replayed", "true")
+
+	c.Status(rec.StatusCode)
+	c.Writer.Write(rec.Response)
+	c.Abort()
+}
+
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func contextUserID(c *gin.Context) uint {
+	userID, _ := c.Get("userID")
+
+	switch v := userID.(type) {
+	case float64:
+		return uint(v)
+	case int:
+		return uint(v)
+	case uint:
+		return v
+	}
+	return 0
+}
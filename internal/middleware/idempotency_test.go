@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"bookstore-api/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type memoryIdempotency struct {
+	mu   sync.Mutex
+	keys map[string]models.IdempotencyKey
+	// inFlight is a key held by the same request sent before
+	inFlight string
+}
+
+func (m *memoryIdempotency) Reserve(rec models.IdempotencyKey, _ time.Duration) (models.IdempotencyKey, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rec.Key == m.inFlight {
+		return rec, false, nil
+	}
+	if existing, ok := m.keys[rec.Key]; ok {
+		return existing, false, nil
+	}
+	m.keys[rec.Key] = rec
+	return rec, true, nil
+}
+
+func (m *memoryIdempotency) Complete(_ uint, key string, status int, headers http.Header, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec := m.keys[key]
+	rec.Completed = true
+	rec.StatusCode = status
+	rec.Headers = headers
+	rec.Response = body
+	m.keys[key] = rec
+	return nil
+}
+
+func (m *memoryIdempotency) Release(_ uint, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.keys[key].Completed {
+		delete(m.keys, key)
+	}
+	return nil
+}
+
+func (m *memoryIdempotency) DeleteExpired(time.Time, time.Duration) error { return nil }
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type request struct {
+		key, body  string
+		wantStatus int
+		wantCalls  int
+		replayed   bool
+	}
+
+	tests := []struct {
+		name     string
+		status   int
+		pending  string
+		requests []request
+	}{
+		{
+			name:   "replays the stored response",
+			status: http.StatusCreated,
+			requests: []request{
+				{key: "a", body: `{"title":"x"}`, wantStatus: http.StatusCreated, wantCalls: 1},
+				{key: "a", body: `{"title":"x"}`, wantStatus: http.StatusCreated, wantCalls: 1, replayed: true},
+			},
+		},
+		{
+			name:   "key reused for another body",
+			status: http.StatusCreated,
+			requests: []request{
+				{key: "a", body: `{"title":"x"}`, wantStatus: http.StatusCreated, wantCalls: 1},
+				{key: "a", body: `{"title":"y"}`, wantStatus: http.StatusUnprocessableEntity, wantCalls: 1},
+			},
+		},
+		{
+			name:    "key in progress",
+			status:  http.StatusCreated,
+			pending: "a",
+			requests: []request{
+				{key: "a", body: `{"title":"x"}`, wantStatus: http.StatusConflict, wantCalls: 0},
+			},
+		},
+		{
+			name:   "server errors are not remembered",
+			status: http.StatusBadGateway,
+			requests: []request{
+				{key: "a", body: `{"title":"x"}`, wantStatus: http.StatusBadGateway, wantCalls: 1},
+				{key: "a", body: `{"title":"x"}`, wantStatus: http.StatusBadGateway, wantCalls: 2},
+			},
+		},
+		{
+			name:   "no key",
+			status: http.StatusCreated,
+			requests: []request{
+				{body: `{"title":"x"}`, wantStatus: http.StatusCreated, wantCalls: 1},
+				{body: `{"title":"x"}`, wantStatus: http.StatusCreated, wantCalls: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryIdempotency{keys: make(map[string]models.IdempotencyKey), inFlight: tt.pending}
+
+			calls := 0
+			r := gin.New()
+			r.Use(Errors(), Idempotency(repo, time.Hour, time.Minute))
+			r.POST("/api/books", func(c *gin.Context) {
+				calls++
+				c.Header("Location", "/api/books/7")
+				c.Header("ETag", `"7-1"`)
+				c.Header("X-Call", strconv.Itoa(calls))
+				c.JSON(tt.status, gin.H{"call": calls})
+			})
+
+			var first *httptest.ResponseRecorder
+			for i, req := range tt.requests {
+				httpReq := httptest.NewRequest(http.MethodPost, "/api/books", strings.NewReader(req.body))
+				if req.key != "" {
+					httpReq.Header.Set(IdempotencyKeyHeader, req.key)
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httpReq)
+
+				if w.Code != req.wantStatus {
+					t.Fatalf("request %d: status %d, want %d", i, w.Code, req.wantStatus)
+				}
+				if calls != req.wantCalls {
+					t.Fatalf("request %d: handler called %d times, want %d", i, calls, req.wantCalls)
+				}
+				if got := w.Header().Get("Idempotent-Replayed") == "true"; got != req.replayed {
+					t.Fatalf("request %d: replayed %v, want %v", i, got, req.replayed)
+				}
+
+				if req.replayed {
+					if w.Body.String() != first.Body.String() {
+						t.Errorf("replayed body %s, want %s", w.Body, first.Body)
+					}
+					for _, h := range []string{"Content-Type", "ETag", "Location"} {
+						if got, want := w.Header().Get(h), first.Header().Get(h); got != want {
+							t.Errorf("replayed %s = %q, want %q", h, got, want)
+						}
+					}
+					if got := w.Header().Get("X-Call"); got != "" {
+						t.Errorf("replayed X-Call = %q, want it left out", got)
+					}
+				}
+				if i == 0 {
+					first = w
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"bookstore-api/internal/lib/i18n"
+	"bookstore-api/internal/lib/sl"
+	"context"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale picks the response language from Accept-Language, or the fallback
+// locale when none of the accepted ones is supported
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setLocale(c, i18n.Match(c.GetHeader("Accept-Language")))
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// LocaleSource returns the locale saved by a user, empty when there is none.
+// It is asked on every request, so it should not go to the database each time
+type LocaleSource interface {
+	Locale(ctx context.Context, userID uint) (string, error)
+}
+
+// UserLocale applies the locale saved by the user over Accept-Language. It
+// runs after JWTAuth, a failed lookup keeps the negotiated locale
+func UserLocale(users LocaleSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale, err := users.Locale(c.Request.Context(), contextUserID(c))
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "middleware.UserLocale", sl.Error(err))
+		}
+		if i18n.Supported(locale) {
+			setLocale(c, locale)
+		}
+
+		c.Next()
+	}
+}
+
+func setLocale(c *gin.Context, locale string) {
+	c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+	c.Header("Content-Language", locale)
+}
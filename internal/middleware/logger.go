@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog writes one slog record per request except for skipPaths, e.g.
+// probes. It runs after RequestID, so the record carries the request ID like
+// the handler logs do
+func AccessLog(skipPaths ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+
+		c.Next()
+
+		if slices.Contains(skipPaths, path) {
+			return
+		}
+
+		level := slog.LevelInfo
+		if c.Writer.Status() >= 500 {
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.Int("status", c.Writer.Status()),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+		if msg := c.Errors.ByType(gin.ErrorTypePrivate).String(); msg != "" {
+			attrs = append(attrs, slog.String("error", msg))
+		}
+
+		slog.LogAttrs(c.Request.Context(), level, "http request", attrs...)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"bookstore-api/internal/lib/reqctx"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	PreferHeader            = "Prefer"
+	PreferenceAppliedHeader = "Preference-Applied"
+	PreferRespondAsync      = "respond-async"
+)
+
+// RespondAsync marks write requests sent with "Prefer: respond-async"
+// (RFC 7240). Handlers that support it answer 202 with a job to poll
+func RespondAsync() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isWriteMethod(c.Request.Method) && prefers(c.Request.Header.Values(PreferHeader), PreferRespondAsync) {
+			c.Request = c.Request.WithContext(reqctx.WithRespondAsync(c.Request.Context()))
+		}
+
+		c.Writer.Header().Add("Vary", PreferHeader)
+		c.Next()
+	}
+}
+
+func prefers(values []string, preference string) bool {
+	for _, v := range values {
+		for _, token := range strings.Split(v, ",") {
+			name, _, _ := strings.Cut(token, ";")
+			name, _, _ = strings.Cut(name, "=")
+			if strings.EqualFold(strings.TrimSpace(name), preference) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,12 +1,17 @@
 package models
 
-// @Description Book model to show it contains
-// @Example {"id":1,"title":"Война и мир","author":"Л. Н. Толстой","price":1300,"user_id":1}
+import "time"
+
+// @Description Book model to show it contains. Version grows with every change, send it back in If-Match to update or delete only the version you have seen
+// @Example {"id":1,"title":"Война и мир","author":"Л. Н. Толстой","price":1300,"user_id":1,"version":2,"created_at":"2025-01-02T15:04:05Z","updated_at":"2025-01-03T10:00:00Z"}
 type Book struct {
-	ID     uint   `json:"id"                gorm:"primarykey"        example:"1"`
-	Title  string `json:"title"                                      example:"Война и мир"   binding:"required"`
-	Author string `json:"author"                                     example:"Л. Н. Толстой" binding:"required"`
-	Price  uint   `json:"price"                                      example:"1300"          binding:"required"`
-	UserID uint   `json:"user_id,omitempty"                          example:"1"`
-	User   User   `json:"-"                 gorm:"foreignKey:UserID"`
+	ID        uint      `json:"id"                gorm:"primarykey"        example:"1"`
+	Title     string    `json:"title"                                      example:"Война и мир"   binding:"required"`
+	Author    string    `json:"author"                                     example:"Л. Н. Толстой" binding:"required"`
+	Price     uint      `json:"price"                                      example:"1300"          binding:"required"`
+	UserID    uint      `json:"user_id,omitempty"                          example:"1"`
+	User      User      `json:"-"                 gorm:"foreignKey:UserID"`
+	Version   uint      `json:"version"           gorm:"not null;default:1" example:"2"`
+	CreatedAt time.Time `json:"created_at"        gorm:"not null"          example:"2025-01-02T15:04:05Z"`
+	UpdatedAt time.Time `json:"updated_at"        gorm:"not null"          example:"2025-01-03T10:00:00Z"`
 }
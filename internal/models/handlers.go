@@ -1,5 +1,7 @@
 package models
 
+import "bookstore-api/internal/lib/errs"
+
 // @Description Basic book information response
 // @Example {"id":1,"title":"Война и мир","author":"Л. Н. Толстой","price":1300}
 type BookResponse struct {
@@ -31,6 +33,52 @@ type BookRequest struct {
 	Price  uint   `json:"price"  binding:"required" example:"1300"`
 }
 
+// Batch modes: atomic rolls back every operation when one fails, per_item
+// keeps the operations that succeeded
+const (
+	BatchModeAtomic  = "atomic"
+	BatchModePerItem = "per_item"
+
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// @Description One operation of a batch. create needs book, update needs id and book, delete needs id
+// @Example {"op":"update","id":3,"book":{"title":"Война и мир","author":"Л. Н. Толстой","price":1300}}
+type BatchOperation struct {
+	Op   string       `json:"op"             binding:"required,oneof=create update delete" example:"update"`
+	ID   uint         `json:"id,omitempty"                                                 example:"3"`
+	Book *BookRequest `json:"book,omitempty"`
+	// Version makes update and delete fail with PRECONDITION_FAILED when
+	// the book changed since, 0 skips the check
+	Version uint `json:"version,omitempty" example:"2"`
+}
+
+// @Description Several book operations executed in one transaction
+// @Example {"mode":"atomic","operations":[{"op":"create","book":{"title":"Война и мир","author":"Л. Н. Толстой","price":1300}},{"op":"delete","id":4}]}
+type BatchRequest struct {
+	Mode       string           `json:"mode"       binding:"omitempty,oneof=atomic per_item" example:"atomic"`
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,dive"`
+}
+
+// @Description Status of one batch operation, error holds a stable error code
+// @Example {"index":0,"op":"create","id":15,"status":201}
+type BatchResult struct {
+	Index  int    `json:"index"           example:"0"`
+	Op     string `json:"op"              example:"create"`
+	ID     uint   `json:"id,omitempty"    example:"15"`
+	Status int    `json:"status"          example:"201"`
+	Error  string `json:"error,omitempty" example:"NOT_FOUND"`
+}
+
+// @Description Batch results in request order. committed is false when an atomic batch was rolled back
+// @Example {"committed":true,"results":[{"index":0,"op":"create","id":15,"status":201},{"index":1,"op":"delete","id":4,"status":200}]}
+type BatchResponse struct {
+	Committed bool          `json:"committed" example:"true"`
+	Results   []BatchResult `json:"results"`
+}
+
 // @Description Detailed book response
 // @Example {"data":{"id":1,"title":"Война и мир","author":"Л. Н. Толстой","price":1300,"user_id":1}}
 type GetBook struct {
@@ -51,10 +99,19 @@ type GetBooks struct {
 	Meta MetaBook `json:"meta"`
 }
 
-// @Description Default error response
-// @Example {"error":"User not found"}
-type ErrorResponse struct {
-	Error string `json:"error" example:"error description"`
+// @Description Error response (RFC 7807). code is a stable identifier from the error catalogue, request_id matches the X-Request-ID header
+// @Example {"type":"urn:bookstore:error:NOT_FOUND","title":"Not found","status":404,"detail":"record not found","instance":"/api/books/13","code":"NOT_FOUND","request_id":"5f0c7a4e-8a8e-4d4b-9d43-1d2b2f1c9e77"}
+type Problem struct {
+	Type      string            `json:"type"                 example:"urn:bookstore:error:NOT_FOUND"`
+	Title     string            `json:"title"                example:"Not found"`
+	Status    int               `json:"status"               example:"404"`
+	Detail    string            `json:"detail,omitempty"     example:"record not found"`
+	Instance  string            `json:"instance,omitempty"   example:"/api/books/13"`
+	Code      errs.Code         `json:"code"                 example:"NOT_FOUND"`
+	RequestID string            `json:"request_id,omitempty" example:"5f0c7a4e-8a8e-4d4b-9d43-1d2b2f1c9e77"`
+	Errors    []errs.FieldError `json:"errors,omitempty"`
+	// Current is the resource as it is now, sent with PRECONDITION_FAILED
+	Current any `json:"current,omitempty" swaggertype:"object"`
 }
 
 // @Description Default successfully response
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// IdempotencyKey stores the outcome of a write request sent with the
+// Idempotency-Key header, so a retried request gets the same answer
+type IdempotencyKey struct {
+	UserID      uint   `gorm:"primaryKey;autoIncrement:false"`
+	Key         string `gorm:"primaryKey;size:255"`
+	RequestHash string `gorm:"size:64;not null"`
+	Completed   bool   `gorm:"not null;default:false"`
+	StatusCode  int
+	// Headers are the response headers replayed with Response
+	Headers   http.Header `gorm:"serializer:json"`
+	Response  []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
+
+// ProcessedRequest marks a keyed Kafka request as already applied to the
+// database. It is written in the same transaction as the change itself,
+// together with the JSON result replayed to duplicates
+type ProcessedRequest struct {
+	UserID    uint   `gorm:"primaryKey;autoIncrement:false"`
+	Key       string `gorm:"primaryKey;size:255"`
+	Method    string
+	Result    []byte
+	CreatedAt time.Time `gorm:"index"`
+}
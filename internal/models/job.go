@@ -0,0 +1,27 @@
+package models
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"encoding/json"
+	"time"
+)
+
+const (
+	JobPending   = "pending"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// @Description Write request executed asynchronously. result holds the worker reply, error a stable error code
+// @Example {"id":"5f0c7a4e-8a8e-4d4b-9d43-1d2b2f1c9e77","method":"PostBookMethod","status":"succeeded","created_at":"2025-06-01T10:00:00Z","updated_at":"2025-06-01T10:00:01Z"}
+type Job struct {
+	ID           string          `json:"id"               gorm:"primaryKey;size:36"      example:"5f0c7a4e-8a8e-4d4b-9d43-1d2b2f1c9e77"`
+	UserID       uint            `json:"-"                gorm:"index;not null"`
+	Method       string          `json:"method"           gorm:"not null"                example:"PostBookMethod"`
+	Status       string          `json:"status"           gorm:"not null"                example:"succeeded"`
+	ErrorCode    errs.Code       `json:"error,omitempty"                                 example:"NOT_FOUND"`
+	ErrorMessage string          `json:"-"`
+	Result       json.RawMessage `json:"result,omitempty" gorm:"type:bytea"             swaggertype:"object"`
+	CreatedAt    time.Time       `json:"created_at"       gorm:"index"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
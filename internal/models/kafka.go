@@ -1,25 +1,94 @@
 package models
 
-import "encoding/json"
+import (
+	"bookstore-api/internal/lib/errs"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
+const (
+	// KafkaSchemaVersion is the major version of the envelope. Adding
+	// optional fields keeps the version, consumers ignore what they don't know
+	KafkaSchemaVersion = 1
+
+	KafkaContentTypeHeader   = "content-type"
+	KafkaSchemaVersionHeader = "schema-version"
+	KafkaContentTypeJSON     = "application/json"
+	KafkaContentTypeProtobuf = "application/x-protobuf"
+)
+
+// Methods and message types of the book request/reply contract shared by
+// the API and the worker
+const (
+	GetAllBooksMethod    = "GetAllBooksMethod"
+	GetUserBooksMethod   = "GetUserBooks"
+	GetBookMethod        = "GetBookMethod"
+	GetBookHistoryMethod = "GetBookHistoryMethod"
+	PostBookMethod       = "PostBookMethod"
+	UpdateBookMethod     = "UpdateBookMethod"
+	DeleteBookMethod     = "DeleteBookMethod"
+	RestoreBookMethod    = "RestoreBookMethod"
+	BatchBookMethod      = "BatchBookMethod"
+	RevertBookMethod     = "RevertBookMethod"
+	// PingMethod is answered by the worker without touching the database,
+	// the API uses it to check the request/reply bus
+	PingMethod = "PingMethod"
+
+	KafkaRequestType  = "request"
+	KafkaResponseType = "response"
+)
+
+// KafkaEnvelope holds the fields shared by requests and responses, enough
+// to route a message before decoding it completely
+type KafkaEnvelope struct {
+	SchemaVersion int    `json:"schema_version"`
+	MessageID     string `json:"message_id"`
+	Method        string `json:"method"`
+	Type          string `json:"type"`
+	RelationID    string `json:"relation_id"`
+}
+
+// KafkaBookRequest carries the payload of method. Actor and Reason are
+// saved with the book revisions the request makes
 type KafkaBookRequest struct {
-	Method     string          `json:"method"`
-	Type       string          `json:"type"`
-	RelationID string          `json:"relation_id"`
-	Payload    json.RawMessage `json:"payload"`
+	SchemaVersion  int             `json:"schema_version"`
+	MessageID      string          `json:"message_id"`
+	Method         string          `json:"method"`
+	Type           string          `json:"type"`
+	RelationID     string          `json:"relation_id"`
+	UserID         uint            `json:"user_id,omitempty"`
+	IdempotencyKey string          `json:"idempotency_key,omitempty"`
+	RequestID      string          `json:"request_id,omitempty"`
+	Actor          string          `json:"actor,omitempty"`
+	Reason         string          `json:"reason,omitempty"`
+	SentAt         time.Time       `json:"sent_at"`
+	Payload        json.RawMessage `json:"payload"`
 }
 
 type KafkaError struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+	Code    errs.Code `json:"code"`
+	Message string    `json:"message,omitempty"`
 }
 
 type KafkaBookResponse struct {
-	Method     string          `json:"method"`
-	Type       string          `json:"type"`
-	RelationID string          `json:"relation_id"`
-	Result     json.RawMessage `json:"result"`
-	Error      KafkaError      `json:"error"`
+	SchemaVersion int             `json:"schema_version"`
+	MessageID     string          `json:"message_id"`
+	Method        string          `json:"method"`
+	Type          string          `json:"type"`
+	RelationID    string          `json:"relation_id"`
+	RequestID     string          `json:"request_id,omitempty"`
+	SentAt        time.Time       `json:"sent_at"`
+	Result        json.RawMessage `json:"result,omitempty"`
+	Error         *KafkaError     `json:"error,omitempty"`
+}
+
+type GetAllBooksRequest struct{}
+
+type PingRequest struct{}
+
+type GetAllBooksResponse struct {
+	Users []UserBooksResponse `json:"users"`
 }
 
 type GetUserBooksRequest struct {
@@ -33,7 +102,110 @@ type GetUserBooksResponse struct {
 	Books []Book `json:"books"`
 }
 
+// DeleteBook deletes the book if it is still at Version, 0 skips the check
 type DeleteBook struct {
+	ID      uint `json:"id"`
+	UserID  uint `json:"user_id"`
+	Version uint `json:"version,omitempty"`
+}
+
+type GetBookRequest struct {
 	ID     uint `json:"id"`
 	UserID uint `json:"user_id"`
 }
+
+// RevertBook sets the book back to Revision. Version is the version the
+// client expects, of the deleted book when it has to be restored, 0 skips
+// the check
+type RevertBook struct {
+	ID       uint `json:"id"`
+	UserID   uint `json:"user_id"`
+	Revision uint `json:"revision"`
+	Version  uint `json:"version,omitempty"`
+}
+
+// BatchBook applies several book operations in one worker transaction
+type BatchBook struct {
+	UserID     uint                 `json:"user_id"`
+	Mode       string               `json:"mode"`
+	Operations []BatchBookOperation `json:"operations"`
+}
+
+// BatchBookOperation carries the book to create, or the ID and new fields
+// of the book to update or delete
+type BatchBookOperation struct {
+	Op   string `json:"op"`
+	Book Book   `json:"book"`
+}
+
+type BatchBookResult struct {
+	Index int         `json:"index"`
+	Op    string      `json:"op"`
+	ID    uint        `json:"id,omitempty"`
+	Error *KafkaError `json:"error,omitempty"`
+}
+
+// BatchBookResponse holds one result per operation in request order.
+// Committed is false when an atomic batch was rolled back
+type BatchBookResponse struct {
+	Committed bool              `json:"committed"`
+	Results   []BatchBookResult `json:"results"`
+}
+
+// NewRequestPayload returns a pointer to the payload type of method, nil
+// for unknown methods
+func NewRequestPayload(method string) interface{} {
+	switch method {
+	case GetAllBooksMethod:
+		return &GetAllBooksRequest{}
+	case GetUserBooksMethod:
+		return &GetUserBooksRequest{}
+	case GetBookMethod:
+		return &GetBookRequest{}
+	case GetBookHistoryMethod:
+		return &GetBookHistoryRequest{}
+	case PostBookMethod, UpdateBookMethod, RestoreBookMethod:
+		return &Book{}
+	case DeleteBookMethod:
+		return &DeleteBook{}
+	case RevertBookMethod:
+		return &RevertBook{}
+	case BatchBookMethod:
+		return &BatchBook{}
+	case PingMethod:
+		return &PingRequest{}
+	}
+	return nil
+}
+
+// NewResult returns a pointer to the result type of method, nil for
+// methods that reply without a result
+func NewResult(method string) interface{} {
+	switch method {
+	case GetAllBooksMethod:
+		return &GetAllBooksResponse{}
+	case GetUserBooksMethod:
+		return &GetUserBooksResponse{}
+	case GetBookMethod, UpdateBookMethod, DeleteBookMethod, RestoreBookMethod, RevertBookMethod:
+		// the book after the update, or its current state when the version
+		// did not match
+		return &Book{}
+	case GetBookHistoryMethod:
+		return &GetBookHistoryResponse{}
+	case BatchBookMethod:
+		return &BatchBookResponse{}
+	}
+	return nil
+}
+
+// Err turns the error received over Kafka back into an errs sentinel, nil
+// when there is no error
+func (e KafkaError) Err() error {
+	if e.Code == "" {
+		return nil
+	}
+	if e.Message == "" {
+		return errs.FromCode(e.Code)
+	}
+	return fmt.Errorf("%w: %s", errs.FromCode(e.Code), e.Message)
+}
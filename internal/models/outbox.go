@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	BookCreatedEvent  = "BookCreated"
+	BookUpdatedEvent  = "BookUpdated"
+	BookDeletedEvent  = "BookDeleted"
+	BookRestoredEvent = "BookRestored"
+	UserDeletedEvent  = "UserDeleted"
+)
+
+// OutboxEvent is a domain event saved in the same transaction as the change
+// it describes and published to Kafka later by the outbox relay
+type OutboxEvent struct {
+	ID            uint       `gorm:"primarykey"`
+	AggregateType string     `gorm:"size:32;not null"`
+	AggregateID   string     `gorm:"size:64;not null"`
+	EventType     string     `gorm:"size:32;not null"`
+	Payload       []byte     `gorm:"not null"`
+	CreatedAt     time.Time  `gorm:"not null"`
+	SentAt        *time.Time `gorm:"index"`
+	// ClaimedBy is the relay publishing the event until ClaimedUntil
+	ClaimedBy    *string `gorm:"size:36"`
+	ClaimedUntil *time.Time
+}
+
+// DomainEvent is the message published to the events topic
+type DomainEvent struct {
+	ID          uint            `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+type UserDeleted struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
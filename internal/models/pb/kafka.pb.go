@@ -0,0 +1,1543 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: bookstore/kafka/v1/kafka.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type BookRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SchemaVersion  uint32                 `protobuf:"varint,1,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	MessageId      string                 `protobuf:"bytes,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Method         string                 `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"`
+	Type           string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	RelationId     string                 `protobuf:"bytes,5,opt,name=relation_id,json=relationId,proto3" json:"relation_id,omitempty"`
+	UserId         uint64                 `protobuf:"varint,6,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,7,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	SentAt         *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"`
+	// payload is encoded with protobuf as well, its type depends on method
+	Payload []byte `protobuf:"bytes,9,opt,name=payload,proto3" json:"payload,omitempty"`
+	// request_id is the X-Request-ID of the HTTP request, for log correlation
+	RequestId string `protobuf:"bytes,10,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// actor and reason are saved with the book revisions the request makes
+	Actor         string `protobuf:"bytes,11,opt,name=actor,proto3" json:"actor,omitempty"`
+	Reason        string `protobuf:"bytes,12,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookRequest) Reset() {
+	*x = BookRequest{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookRequest) ProtoMessage() {}
+
+func (x *BookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookRequest.ProtoReflect.Descriptor instead.
+func (*BookRequest) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BookRequest) GetSchemaVersion() uint32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *BookRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *BookRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *BookRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *BookRequest) GetRelationId() string {
+	if x != nil {
+		return x.RelationId
+	}
+	return ""
+}
+
+func (x *BookRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *BookRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *BookRequest) GetSentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentAt
+	}
+	return nil
+}
+
+func (x *BookRequest) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *BookRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *BookRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *BookRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type KafkaError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KafkaError) Reset() {
+	*x = KafkaError{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KafkaError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KafkaError) ProtoMessage() {}
+
+func (x *KafkaError) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KafkaError.ProtoReflect.Descriptor instead.
+func (*KafkaError) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KafkaError) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *KafkaError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type BookResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SchemaVersion uint32                 `protobuf:"varint,1,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	MessageId     string                 `protobuf:"bytes,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Method        string                 `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"`
+	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	RelationId    string                 `protobuf:"bytes,5,opt,name=relation_id,json=relationId,proto3" json:"relation_id,omitempty"`
+	SentAt        *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"`
+	Result        []byte                 `protobuf:"bytes,9,opt,name=result,proto3" json:"result,omitempty"`
+	Error         *KafkaError            `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	RequestId     string                 `protobuf:"bytes,11,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookResponse) Reset() {
+	*x = BookResponse{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookResponse) ProtoMessage() {}
+
+func (x *BookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookResponse.ProtoReflect.Descriptor instead.
+func (*BookResponse) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BookResponse) GetSchemaVersion() uint32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *BookResponse) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *BookResponse) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *BookResponse) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *BookResponse) GetRelationId() string {
+	if x != nil {
+		return x.RelationId
+	}
+	return ""
+}
+
+func (x *BookResponse) GetSentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentAt
+	}
+	return nil
+}
+
+func (x *BookResponse) GetResult() []byte {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *BookResponse) GetError() *KafkaError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *BookResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type Book struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title  string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Author string                 `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
+	Price  uint64                 `protobuf:"varint,4,opt,name=price,proto3" json:"price,omitempty"`
+	UserId uint64                 `protobuf:"varint,5,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// version counts the changes of the book. In UpdateBook and batch
+	// operations it is the version the client expects, 0 skips the check
+	Version       uint64                 `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Book) Reset() {
+	*x = Book{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Book) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Book) ProtoMessage() {}
+
+func (x *Book) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Book.ProtoReflect.Descriptor instead.
+func (*Book) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Book) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Book) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Book) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Book) GetPrice() uint64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Book) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Book) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Book) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Book) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type GetBookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        uint64                 `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBookRequest) Reset() {
+	*x = GetBookRequest{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBookRequest) ProtoMessage() {}
+
+func (x *GetBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBookRequest.ProtoReflect.Descriptor instead.
+func (*GetBookRequest) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetBookRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *GetBookRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type GetBookHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        uint64                 `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBookHistoryRequest) Reset() {
+	*x = GetBookHistoryRequest{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBookHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBookHistoryRequest) ProtoMessage() {}
+
+func (x *GetBookHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBookHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetBookHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetBookHistoryRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *GetBookHistoryRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type BookRevision struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	BookId        uint64                 `protobuf:"varint,2,opt,name=book_id,json=bookId,proto3" json:"book_id,omitempty"`
+	UserId        uint64                 `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Version       uint64                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	Operation     string                 `protobuf:"bytes,5,opt,name=operation,proto3" json:"operation,omitempty"`
+	Title         string                 `protobuf:"bytes,6,opt,name=title,proto3" json:"title,omitempty"`
+	Author        string                 `protobuf:"bytes,7,opt,name=author,proto3" json:"author,omitempty"`
+	Price         uint64                 `protobuf:"varint,8,opt,name=price,proto3" json:"price,omitempty"`
+	Actor         string                 `protobuf:"bytes,9,opt,name=actor,proto3" json:"actor,omitempty"`
+	Reason        string                 `protobuf:"bytes,10,opt,name=reason,proto3" json:"reason,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookRevision) Reset() {
+	*x = BookRevision{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookRevision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookRevision) ProtoMessage() {}
+
+func (x *BookRevision) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookRevision.ProtoReflect.Descriptor instead.
+func (*BookRevision) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BookRevision) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *BookRevision) GetBookId() uint64 {
+	if x != nil {
+		return x.BookId
+	}
+	return 0
+}
+
+func (x *BookRevision) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *BookRevision) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *BookRevision) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *BookRevision) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *BookRevision) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *BookRevision) GetPrice() uint64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *BookRevision) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *BookRevision) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *BookRevision) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type GetBookHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Revisions     []*BookRevision        `protobuf:"bytes,1,rep,name=revisions,proto3" json:"revisions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBookHistoryResponse) Reset() {
+	*x = GetBookHistoryResponse{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBookHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBookHistoryResponse) ProtoMessage() {}
+
+func (x *GetBookHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBookHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetBookHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetBookHistoryResponse) GetRevisions() []*BookRevision {
+	if x != nil {
+		return x.Revisions
+	}
+	return nil
+}
+
+type GetAllBooksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAllBooksRequest) Reset() {
+	*x = GetAllBooksRequest{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAllBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAllBooksRequest) ProtoMessage() {}
+
+func (x *GetAllBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAllBooksRequest.ProtoReflect.Descriptor instead.
+func (*GetAllBooksRequest) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{8}
+}
+
+type PingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingRequest) Reset() {
+	*x = PingRequest{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingRequest) ProtoMessage() {}
+
+func (x *PingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{9}
+}
+
+type UserBooks struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	TotalBooks    int64                  `protobuf:"varint,2,opt,name=total_books,json=totalBooks,proto3" json:"total_books,omitempty"`
+	Books         []*Book                `protobuf:"bytes,3,rep,name=books,proto3" json:"books,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserBooks) Reset() {
+	*x = UserBooks{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserBooks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserBooks) ProtoMessage() {}
+
+func (x *UserBooks) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserBooks.ProtoReflect.Descriptor instead.
+func (*UserBooks) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UserBooks) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *UserBooks) GetTotalBooks() int64 {
+	if x != nil {
+		return x.TotalBooks
+	}
+	return 0
+}
+
+func (x *UserBooks) GetBooks() []*Book {
+	if x != nil {
+		return x.Books
+	}
+	return nil
+}
+
+type GetAllBooksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*UserBooks           `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAllBooksResponse) Reset() {
+	*x = GetAllBooksResponse{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAllBooksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAllBooksResponse) ProtoMessage() {}
+
+func (x *GetAllBooksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAllBooksResponse.ProtoReflect.Descriptor instead.
+func (*GetAllBooksResponse) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetAllBooksResponse) GetUsers() []*UserBooks {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+type GetUserBooksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Author        string                 `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Title         string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Limit         int64                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserBooksRequest) Reset() {
+	*x = GetUserBooksRequest{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserBooksRequest) ProtoMessage() {}
+
+func (x *GetUserBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserBooksRequest.ProtoReflect.Descriptor instead.
+func (*GetUserBooksRequest) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetUserBooksRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *GetUserBooksRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *GetUserBooksRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *GetUserBooksRequest) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type GetUserBooksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Books         []*Book                `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserBooksResponse) Reset() {
+	*x = GetUserBooksResponse{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserBooksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserBooksResponse) ProtoMessage() {}
+
+func (x *GetUserBooksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserBooksResponse.ProtoReflect.Descriptor instead.
+func (*GetUserBooksResponse) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetUserBooksResponse) GetBooks() []*Book {
+	if x != nil {
+		return x.Books
+	}
+	return nil
+}
+
+type DeleteBook struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId uint64                 `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// version the client expects, 0 skips the check
+	Version       uint64 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteBook) Reset() {
+	*x = DeleteBook{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteBook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteBook) ProtoMessage() {}
+
+func (x *DeleteBook) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteBook.ProtoReflect.Descriptor instead.
+func (*DeleteBook) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DeleteBook) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteBook) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *DeleteBook) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// RevertBook sets a book back to one of its revisions
+type RevertBook struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId   uint64                 `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Revision uint64                 `protobuf:"varint,3,opt,name=revision,proto3" json:"revision,omitempty"`
+	// version the client expects, 0 skips the check
+	Version       uint64 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevertBook) Reset() {
+	*x = RevertBook{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevertBook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevertBook) ProtoMessage() {}
+
+func (x *RevertBook) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevertBook.ProtoReflect.Descriptor instead.
+func (*RevertBook) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RevertBook) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *RevertBook) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *RevertBook) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *RevertBook) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type BatchBookOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Op            string                 `protobuf:"bytes,1,opt,name=op,proto3" json:"op,omitempty"`
+	Book          *Book                  `protobuf:"bytes,2,opt,name=book,proto3" json:"book,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchBookOperation) Reset() {
+	*x = BatchBookOperation{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchBookOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchBookOperation) ProtoMessage() {}
+
+func (x *BatchBookOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchBookOperation.ProtoReflect.Descriptor instead.
+func (*BatchBookOperation) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BatchBookOperation) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *BatchBookOperation) GetBook() *Book {
+	if x != nil {
+		return x.Book
+	}
+	return nil
+}
+
+type BatchBook struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Mode          string                 `protobuf:"bytes,2,opt,name=mode,proto3" json:"mode,omitempty"`
+	Operations    []*BatchBookOperation  `protobuf:"bytes,3,rep,name=operations,proto3" json:"operations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchBook) Reset() {
+	*x = BatchBook{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchBook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchBook) ProtoMessage() {}
+
+func (x *BatchBook) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchBook.ProtoReflect.Descriptor instead.
+func (*BatchBook) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BatchBook) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *BatchBook) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *BatchBook) GetOperations() []*BatchBookOperation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+type BatchBookResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int64                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Op            string                 `protobuf:"bytes,2,opt,name=op,proto3" json:"op,omitempty"`
+	Id            uint64                 `protobuf:"varint,3,opt,name=id,proto3" json:"id,omitempty"`
+	Error         *KafkaError            `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchBookResult) Reset() {
+	*x = BatchBookResult{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchBookResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchBookResult) ProtoMessage() {}
+
+func (x *BatchBookResult) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchBookResult.ProtoReflect.Descriptor instead.
+func (*BatchBookResult) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BatchBookResult) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *BatchBookResult) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *BatchBookResult) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *BatchBookResult) GetError() *KafkaError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+type BatchBookResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Committed     bool                   `protobuf:"varint,1,opt,name=committed,proto3" json:"committed,omitempty"`
+	Results       []*BatchBookResult     `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchBookResponse) Reset() {
+	*x = BatchBookResponse{}
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchBookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchBookResponse) ProtoMessage() {}
+
+func (x *BatchBookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bookstore_kafka_v1_kafka_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchBookResponse.ProtoReflect.Descriptor instead.
+func (*BatchBookResponse) Descriptor() ([]byte, []int) {
+	return file_bookstore_kafka_v1_kafka_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BatchBookResponse) GetCommitted() bool {
+	if x != nil {
+		return x.Committed
+	}
+	return false
+}
+
+func (x *BatchBookResponse) GetResults() []*BatchBookResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+var File_bookstore_kafka_v1_kafka_proto protoreflect.FileDescriptor
+
+const file_bookstore_kafka_v1_kafka_proto_rawDesc = "" +
+	"\n" +
+	"\x1ebookstore/kafka/v1/kafka.proto\x12\x12bookstore.kafka.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xfe\x02\n" +
+	"\vBookRequest\x12%\n" +
+	"\x0eschema_version\x18\x01 \x01(\rR\rschemaVersion\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\tR\tmessageId\x12\x16\n" +
+	"\x06method\x18\x03 \x01(\tR\x06method\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x1f\n" +
+	"\vrelation_id\x18\x05 \x01(\tR\n" +
+	"relationId\x12\x17\n" +
+	"\auser_id\x18\x06 \x01(\x04R\x06userId\x12'\n" +
+	"\x0fidempotency_key\x18\a \x01(\tR\x0eidempotencyKey\x123\n" +
+	"\asent_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x06sentAt\x12\x18\n" +
+	"\apayload\x18\t \x01(\fR\apayload\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\n" +
+	" \x01(\tR\trequestId\x12\x14\n" +
+	"\x05actor\x18\v \x01(\tR\x05actor\x12\x16\n" +
+	"\x06reason\x18\f \x01(\tR\x06reason\":\n" +
+	"\n" +
+	"KafkaError\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xc3\x02\n" +
+	"\fBookResponse\x12%\n" +
+	"\x0eschema_version\x18\x01 \x01(\rR\rschemaVersion\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\tR\tmessageId\x12\x16\n" +
+	"\x06method\x18\x03 \x01(\tR\x06method\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x1f\n" +
+	"\vrelation_id\x18\x05 \x01(\tR\n" +
+	"relationId\x123\n" +
+	"\asent_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x06sentAt\x12\x16\n" +
+	"\x06result\x18\t \x01(\fR\x06result\x124\n" +
+	"\x05error\x18\n" +
+	" \x01(\v2\x1e.bookstore.kafka.v1.KafkaErrorR\x05error\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\v \x01(\tR\trequestId\"\x83\x02\n" +
+	"\x04Book\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
+	"\x06author\x18\x03 \x01(\tR\x06author\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x04R\x05price\x12\x17\n" +
+	"\auser_id\x18\x05 \x01(\x04R\x06userId\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\x04R\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"9\n" +
+	"\x0eGetBookRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x04R\x06userId\"@\n" +
+	"\x15GetBookHistoryRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x04R\x06userId\"\xb5\x02\n" +
+	"\fBookRevision\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x17\n" +
+	"\abook_id\x18\x02 \x01(\x04R\x06bookId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x04R\x06userId\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x04R\aversion\x12\x1c\n" +
+	"\toperation\x18\x05 \x01(\tR\toperation\x12\x14\n" +
+	"\x05title\x18\x06 \x01(\tR\x05title\x12\x16\n" +
+	"\x06author\x18\a \x01(\tR\x06author\x12\x14\n" +
+	"\x05price\x18\b \x01(\x04R\x05price\x12\x14\n" +
+	"\x05actor\x18\t \x01(\tR\x05actor\x12\x16\n" +
+	"\x06reason\x18\n" +
+	" \x01(\tR\x06reason\x129\n" +
+	"\n" +
+	"created_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"X\n" +
+	"\x16GetBookHistoryResponse\x12>\n" +
+	"\trevisions\x18\x01 \x03(\v2 .bookstore.kafka.v1.BookRevisionR\trevisions\"\x14\n" +
+	"\x12GetAllBooksRequest\"\r\n" +
+	"\vPingRequest\"x\n" +
+	"\tUserBooks\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1f\n" +
+	"\vtotal_books\x18\x02 \x01(\x03R\n" +
+	"totalBooks\x12.\n" +
+	"\x05books\x18\x03 \x03(\v2\x18.bookstore.kafka.v1.BookR\x05books\"J\n" +
+	"\x13GetAllBooksResponse\x123\n" +
+	"\x05users\x18\x01 \x03(\v2\x1d.bookstore.kafka.v1.UserBooksR\x05users\"r\n" +
+	"\x13GetUserBooksRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12\x16\n" +
+	"\x06author\x18\x02 \x01(\tR\x06author\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x03R\x05limit\"F\n" +
+	"\x14GetUserBooksResponse\x12.\n" +
+	"\x05books\x18\x01 \x03(\v2\x18.bookstore.kafka.v1.BookR\x05books\"O\n" +
+	"\n" +
+	"DeleteBook\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x04R\x06userId\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x04R\aversion\"k\n" +
+	"\n" +
+	"RevertBook\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x04R\x06userId\x12\x1a\n" +
+	"\brevision\x18\x03 \x01(\x04R\brevision\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x04R\aversion\"R\n" +
+	"\x12BatchBookOperation\x12\x0e\n" +
+	"\x02op\x18\x01 \x01(\tR\x02op\x12,\n" +
+	"\x04book\x18\x02 \x01(\v2\x18.bookstore.kafka.v1.BookR\x04book\"\x80\x01\n" +
+	"\tBatchBook\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\tR\x04mode\x12F\n" +
+	"\n" +
+	"operations\x18\x03 \x03(\v2&.bookstore.kafka.v1.BatchBookOperationR\n" +
+	"operations\"}\n" +
+	"\x0fBatchBookResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x03R\x05index\x12\x0e\n" +
+	"\x02op\x18\x02 \x01(\tR\x02op\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\x04R\x02id\x124\n" +
+	"\x05error\x18\x04 \x01(\v2\x1e.bookstore.kafka.v1.KafkaErrorR\x05error\"p\n" +
+	"\x11BatchBookResponse\x12\x1c\n" +
+	"\tcommitted\x18\x01 \x01(\bR\tcommitted\x12=\n" +
+	"\aresults\x18\x02 \x03(\v2#.bookstore.kafka.v1.BatchBookResultR\aresultsB%Z#bookstore-api/internal/models/pb;pbb\x06proto3"
+
+var (
+	file_bookstore_kafka_v1_kafka_proto_rawDescOnce sync.Once
+	file_bookstore_kafka_v1_kafka_proto_rawDescData []byte
+)
+
+func file_bookstore_kafka_v1_kafka_proto_rawDescGZIP() []byte {
+	file_bookstore_kafka_v1_kafka_proto_rawDescOnce.Do(func() {
+		file_bookstore_kafka_v1_kafka_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bookstore_kafka_v1_kafka_proto_rawDesc), len(file_bookstore_kafka_v1_kafka_proto_rawDesc)))
+	})
+	return file_bookstore_kafka_v1_kafka_proto_rawDescData
+}
+
+var file_bookstore_kafka_v1_kafka_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_bookstore_kafka_v1_kafka_proto_goTypes = []any{
+	(*BookRequest)(nil),            // 0: bookstore.kafka.v1.BookRequest
+	(*KafkaError)(nil),             // 1: bookstore.kafka.v1.KafkaError
+	(*BookResponse)(nil),           // 2: bookstore.kafka.v1.BookResponse
+	(*Book)(nil),                   // 3: bookstore.kafka.v1.Book
+	(*GetBookRequest)(nil),         // 4: bookstore.kafka.v1.GetBookRequest
+	(*GetBookHistoryRequest)(nil),  // 5: bookstore.kafka.v1.GetBookHistoryRequest
+	(*BookRevision)(nil),           // 6: bookstore.kafka.v1.BookRevision
+	(*GetBookHistoryResponse)(nil), // 7: bookstore.kafka.v1.GetBookHistoryResponse
+	(*GetAllBooksRequest)(nil),     // 8: bookstore.kafka.v1.GetAllBooksRequest
+	(*PingRequest)(nil),            // 9: bookstore.kafka.v1.PingRequest
+	(*UserBooks)(nil),              // 10: bookstore.kafka.v1.UserBooks
+	(*GetAllBooksResponse)(nil),    // 11: bookstore.kafka.v1.GetAllBooksResponse
+	(*GetUserBooksRequest)(nil),    // 12: bookstore.kafka.v1.GetUserBooksRequest
+	(*GetUserBooksResponse)(nil),   // 13: bookstore.kafka.v1.GetUserBooksResponse
+	(*DeleteBook)(nil),             // 14: bookstore.kafka.v1.DeleteBook
+	(*RevertBook)(nil),             // 15: bookstore.kafka.v1.RevertBook
+	(*BatchBookOperation)(nil),     // 16: bookstore.kafka.v1.BatchBookOperation
+	(*BatchBook)(nil),              // 17: bookstore.kafka.v1.BatchBook
+	(*BatchBookResult)(nil),        // 18: bookstore.kafka.v1.BatchBookResult
+	(*BatchBookResponse)(nil),      // 19: bookstore.kafka.v1.BatchBookResponse
+	(*timestamppb.Timestamp)(nil),  // 20: google.protobuf.Timestamp
+}
+var file_bookstore_kafka_v1_kafka_proto_depIdxs = []int32{
+	20, // 0: bookstore.kafka.v1.BookRequest.sent_at:type_name -> google.protobuf.Timestamp
+	20, // 1: bookstore.kafka.v1.BookResponse.sent_at:type_name -> google.protobuf.Timestamp
+	1,  // 2: bookstore.kafka.v1.BookResponse.error:type_name -> bookstore.kafka.v1.KafkaError
+	20, // 3: bookstore.kafka.v1.Book.created_at:type_name -> google.protobuf.Timestamp
+	20, // 4: bookstore.kafka.v1.Book.updated_at:type_name -> google.protobuf.Timestamp
+	20, // 5: bookstore.kafka.v1.BookRevision.created_at:type_name -> google.protobuf.Timestamp
+	6,  // 6: bookstore.kafka.v1.GetBookHistoryResponse.revisions:type_name -> bookstore.kafka.v1.BookRevision
+	3,  // 7: bookstore.kafka.v1.UserBooks.books:type_name -> bookstore.kafka.v1.Book
+	10, // 8: bookstore.kafka.v1.GetAllBooksResponse.users:type_name -> bookstore.kafka.v1.UserBooks
+	3,  // 9: bookstore.kafka.v1.GetUserBooksResponse.books:type_name -> bookstore.kafka.v1.Book
+	3,  // 10: bookstore.kafka.v1.BatchBookOperation.book:type_name -> bookstore.kafka.v1.Book
+	16, // 11: bookstore.kafka.v1.BatchBook.operations:type_name -> bookstore.kafka.v1.BatchBookOperation
+	1,  // 12: bookstore.kafka.v1.BatchBookResult.error:type_name -> bookstore.kafka.v1.KafkaError
+	18, // 13: bookstore.kafka.v1.BatchBookResponse.results:type_name -> bookstore.kafka.v1.BatchBookResult
+	14, // [14:14] is the sub-list for method output_type
+	14, // [14:14] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_bookstore_kafka_v1_kafka_proto_init() }
+func file_bookstore_kafka_v1_kafka_proto_init() {
+	if File_bookstore_kafka_v1_kafka_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bookstore_kafka_v1_kafka_proto_rawDesc), len(file_bookstore_kafka_v1_kafka_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_bookstore_kafka_v1_kafka_proto_goTypes,
+		DependencyIndexes: file_bookstore_kafka_v1_kafka_proto_depIdxs,
+		MessageInfos:      file_bookstore_kafka_v1_kafka_proto_msgTypes,
+	}.Build()
+	File_bookstore_kafka_v1_kafka_proto = out.File
+	file_bookstore_kafka_v1_kafka_proto_goTypes = nil
+	file_bookstore_kafka_v1_kafka_proto_depIdxs = nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// RateLimitOverride replaces the limit of the /api routes for one user
+type RateLimitOverride struct {
+	UserID    uint          `gorm:"primaryKey;autoIncrement:false"`
+	User      User          `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Requests  int           `gorm:"not null"`
+	Period    time.Duration `gorm:"not null"`
+	Burst     int           `gorm:"not null;default:0"`
+	UpdatedAt time.Time     `gorm:"not null"`
+}
+
+// @Description Token bucket of a user: burst requests at once, refilled at requests per period. A zero burst means requests
+// @Example {"requests":600,"period":"1m","burst":50}
+type RateLimitRequest struct {
+	Requests int    `json:"requests" binding:"required,min=1" example:"600"`
+	Period   string `json:"period"   binding:"required"       example:"1m"`
+	Burst    int    `json:"burst"    binding:"min=0"          example:"50"`
+}
+
+// @Description Rate limit set for a user
+// @Example {"username":"Wladim1r","requests":600,"period":"1m0s","burst":50}
+type RateLimitResponse struct {
+	Username string `json:"username" example:"Wladim1r"`
+	Requests int    `json:"requests" example:"600"`
+	Period   string `json:"period"   example:"1m0s"`
+	Burst    int    `json:"burst"    example:"50"`
+}
+
+// @Description Rate limits set for single users
+type RateLimitsResponse struct {
+	Overrides []RateLimitResponse `json:"overrides"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Operations recorded in the history of a book
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+)
+
+// BookRevision is a snapshot of a book after a change, or right before it
+// was deleted. Revisions are kept after the book is gone
+type BookRevision struct {
+	ID        uint      `json:"id"               gorm:"primarykey"`
+	BookID    uint      `json:"book_id"          gorm:"not null"`
+	UserID    uint      `json:"user_id"          gorm:"not null"`
+	Version   uint      `json:"version"          gorm:"not null"`
+	Operation string    `json:"operation"        gorm:"size:16;not null"`
+	Title     string    `json:"title"            gorm:"not null"`
+	Author    string    `json:"author"           gorm:"not null"`
+	Price     uint      `json:"price"            gorm:"not null"`
+	Actor     string    `json:"actor"            gorm:"size:64;not null"`
+	Reason    string    `json:"reason,omitempty" gorm:"size:255;not null;default:''"`
+	CreatedAt time.Time `json:"created_at"       gorm:"not null"`
+}
+
+type GetBookHistoryRequest struct {
+	ID     uint `json:"id"`
+	UserID uint `json:"user_id"`
+}
+
+// GetBookHistoryResponse holds the revisions of a book, oldest first
+type GetBookHistoryResponse struct {
+	Revisions []BookRevision `json:"revisions"`
+}
+
+// @Description Field of the book changed by a revision, from is null for a created book
+// @Example {"field":"price","from":1300,"to":1100}
+type FieldChange struct {
+	Field string `json:"field" example:"price"`
+	From  any    `json:"from"  swaggertype:"object"`
+	To    any    `json:"to"    swaggertype:"object"`
+}
+
+// @Description Revision of a book: the book as it was after the change, or before the deletion, and the fields the change touched
+// @Example {"revision":7,"version":2,"operation":"update","title":"Война и мир","author":"Л. Н. Толстой","price":1100,"actor":"user:1","reason":"discount","created_at":"2025-01-03T10:00:00Z","changes":[{"field":"price","from":1300,"to":1100}]}
+type RevisionResponse struct {
+	Revision  uint          `json:"revision"         example:"7"`
+	Version   uint          `json:"version"          example:"2"`
+	Operation string        `json:"operation"        example:"update"         enums:"create,update,delete,restore"`
+	Title     string        `json:"title"            example:"Война и мир"`
+	Author    string        `json:"author"           example:"Л. Н. Толстой"`
+	Price     uint          `json:"price"            example:"1100"`
+	Actor     string        `json:"actor"            example:"user:1"`
+	Reason    string        `json:"reason,omitempty" example:"discount"`
+	CreatedAt time.Time     `json:"created_at"       example:"2025-01-03T10:00:00Z"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+// @Description History of a book, oldest revision first
+type BookHistoryResponse struct {
+	BookID uint               `json:"book_id" example:"1"`
+	Data   []RevisionResponse `json:"data"`
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"log/slog"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -11,12 +13,21 @@ type Request struct {
 	Password string `json:"password" binding:"required" example:"12345qwerty"`
 }
 
+// LogValue keeps the password out of the logs
+func (r Request) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("username", r.Username),
+		slog.String("password", "[REDACTED]"),
+	)
+}
+
 // @Description User data model
 // @Example {"id":1,"username":"Wladim1r"}
 type User struct {
 	ID       uint   `json:"id"       gorm:"primarykey"                                    example:"1"`
 	Username string `json:"username" gorm:"unique"                                        example:"Wladim1r" binding:"required"`
 	Password string `json:"-"`
+	Locale   string `json:"-"        gorm:"size:8;not null;default:''"`
 	Books    []Book `json:"-"        gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
 
@@ -34,6 +45,12 @@ func (u *User) CheckPassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 }
 
+// @Description Preferred language of the user, empty to follow Accept-Language
+// @Example {"locale":"en"}
+type LocaleRequest struct {
+	Locale string `json:"locale" example:"en"`
+}
+
 // @Description User response including ID, username and quantity of books
 // @Example {"id":1,"username":"Wladim1r","total":10}
 type UserResponse struct {
@@ -0,0 +1,172 @@
+package perskafka
+
+import (
+	"bookstore-api/internal/lib/errs"
+	kconf "bookstore-api/internal/perskafka/config"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+const adminTimeout = 30 * time.Second
+
+// TopicSpec describes a topic the services depend on
+type TopicSpec struct {
+	Name              string
+	Partitions        int
+	ReplicationFactor int
+	Retention         time.Duration
+}
+
+// EnsureTopics checks that the broker is reachable and every topic exists
+// with at least the wanted partitions and replicas. Missing topics are
+// created when create is true, otherwise they are reported as an error
+func EnsureTopics(cfg kconf.Config, topics []TopicSpec, create bool) error {
+	config := &kafka.ConfigMap{}
+	if err := cfg.Apply(config); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrKafkaAdmin, err)
+	}
+
+	admin, err := kafka.NewAdminClient(config)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrKafkaAdmin, err)
+	}
+	defer admin.Close()
+
+	md, err := admin.GetMetadata(nil, true, int(adminTimeout.Milliseconds()))
+	if err != nil {
+		return fmt.Errorf("%w: broker %s is unreachable: %v", errs.ErrKafkaAdmin, cfg.Brokers, err)
+	}
+
+	var missing []kafka.TopicSpecification
+	var problems []string
+	for _, t := range topics {
+		existing, ok := md.Topics[t.Name]
+		if !ok || existing.Error.Code() == kafka.ErrUnknownTopicOrPart {
+			missing = append(missing, kafka.TopicSpecification{
+				Topic:             t.Name,
+				NumPartitions:     t.Partitions,
+				ReplicationFactor: t.ReplicationFactor,
+				Config: map[string]string{
+					"retention.ms": strconv.FormatInt(t.Retention.Milliseconds(), 10),
+				},
+			})
+			continue
+		}
+		if existing.Error.Code() != kafka.ErrNoError {
+			problems = append(problems, fmt.Sprintf("topic %q: %v", t.Name, existing.Error))
+			continue
+		}
+
+		if len(existing.Partitions) < t.Partitions {
+			problems = append(problems, fmt.Sprintf(
+				"topic %q has %d partitions, want at least %d",
+				t.Name, len(existing.Partitions), t.Partitions,
+			))
+		}
+		for _, p := range existing.Partitions {
+			if len(p.Replicas) < t.ReplicationFactor {
+				problems = append(problems, fmt.Sprintf(
+					"topic %q partition %d has %d replicas, want %d",
+					t.Name, p.ID, len(p.Replicas), t.ReplicationFactor,
+				))
+				break
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", errs.ErrKafkaAdmin, strings.Join(problems, "; "))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), adminTimeout)
+	defer cancel()
+
+	if err := checkRetention(ctx, admin, topics, missing); err != nil {
+		return err
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+	if !create {
+		names := make([]string, len(missing))
+		for i, m := range missing {
+			names[i] = m.Topic
+		}
+		return fmt.Errorf("%w: missing topics %s", errs.ErrKafkaAdmin, strings.Join(names, ", "))
+	}
+
+	results, err := admin.CreateTopics(ctx, missing, kafka.SetAdminOperationTimeout(adminTimeout))
+	if err != nil {
+		return fmt.Errorf("%w: create topics: %v", errs.ErrKafkaAdmin, err)
+	}
+
+	var createErrs []error
+	for _, res := range results {
+		switch res.Error.Code() {
+		case kafka.ErrNoError:
+			slog.Info("kafka topic created", "topic", res.Topic)
+		case kafka.ErrTopicAlreadyExists:
+			// another instance created it first
+		default:
+			createErrs = append(createErrs, fmt.Errorf("topic %q: %v", res.Topic, res.Error))
+		}
+	}
+	if err := errors.Join(createErrs...); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrKafkaAdmin, err)
+	}
+
+	return nil
+}
+
+// checkRetention warns about existing topics whose retention differs from
+// the configured one. Retention is an operational choice, so it doesn't stop
+// the service
+func checkRetention(ctx context.Context, admin *kafka.AdminClient, topics []TopicSpec, missing []kafka.TopicSpecification) error {
+	isMissing := make(map[string]bool, len(missing))
+	for _, m := range missing {
+		isMissing[m.Topic] = true
+	}
+
+	var resources []kafka.ConfigResource
+	want := make(map[string]time.Duration)
+	for _, t := range topics {
+		if isMissing[t.Name] {
+			continue
+		}
+		resources = append(resources, kafka.ConfigResource{Type: kafka.ResourceTopic, Name: t.Name})
+		want[t.Name] = t.Retention
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+
+	results, err := admin.DescribeConfigs(ctx, resources)
+	if err != nil {
+		return fmt.Errorf("%w: describe topics: %v", errs.ErrKafkaAdmin, err)
+	}
+
+	for _, res := range results {
+		entry, ok := res.Config["retention.ms"]
+		if !ok {
+			continue
+		}
+		ms, err := strconv.ParseInt(entry.Value, 10, 64)
+		if err != nil {
+			continue
+		}
+		if got := time.Duration(ms) * time.Millisecond; got != want[res.Name] {
+			slog.Warn("kafka topic retention differs from config",
+				"topic", res.Name, "retention", got, "want", want[res.Name])
+		}
+	}
+
+	return nil
+}
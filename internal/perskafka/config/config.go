@@ -0,0 +1,101 @@
+package perskafka
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Config holds the connection settings shared by producers and consumers.
+// Secrets are read from files so they can be mounted from a secret store
+type Config struct {
+	Brokers string
+
+	// SecurityProtocol is one of plaintext, ssl, sasl_plaintext, sasl_ssl
+	SecurityProtocol string
+
+	// SASLMechanism is PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512
+	SASLMechanism    string
+	SASLUsernameFile string
+	SASLPasswordFile string
+
+	SSLCALocation      string
+	SSLCertLocation    string
+	SSLKeyLocation     string
+	SSLKeyPasswordFile string
+
+	// Overrides are raw librdkafka properties applied last
+	Overrides map[string]string
+}
+
+// ParseOverrides parses "key=value,key=value"
+func ParseOverrides(s string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("kafka override %q: want key=value", pair)
+		}
+		overrides[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return overrides, nil
+}
+
+// Apply adds the connection and security settings to cm
+func (c Config) Apply(cm *kafka.ConfigMap) error {
+	set := func(key, value string) error {
+		if value == "" {
+			return nil
+		}
+		return cm.SetKey(key, value)
+	}
+	setFile := func(key, path string) error {
+		if path == "" {
+			return nil
+		}
+		value, err := readSecret(path)
+		if err != nil {
+			return err
+		}
+		return cm.SetKey(key, value)
+	}
+
+	steps := []error{
+		set("bootstrap.servers", c.Brokers),
+		set("security.protocol", c.SecurityProtocol),
+		set("sasl.mechanism", c.SASLMechanism),
+		setFile("sasl.username", c.SASLUsernameFile),
+		setFile("sasl.password", c.SASLPasswordFile),
+		set("ssl.ca.location", c.SSLCALocation),
+		set("ssl.certificate.location", c.SSLCertLocation),
+		set("ssl.key.location", c.SSLKeyLocation),
+		setFile("ssl.key.password", c.SSLKeyPasswordFile),
+	}
+	if err := errors.Join(steps...); err != nil {
+		return err
+	}
+
+	for key, value := range c.Overrides {
+		if err := cm.SetKey(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read kafka secret: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
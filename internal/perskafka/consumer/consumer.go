@@ -1,44 +1,44 @@
 package perskafka
 
 import (
-	"sync"
+	"bookstore-api/internal/metrics"
+	kconf "bookstore-api/internal/perskafka/config"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 )
 
-var (
-	consumer     *kafka.Consumer
-	consumerOnce sync.Once
-	consumerErr  error
-)
-
-func GetConsumer(address, groupID string) (*kafka.Consumer, error) {
-	consumerOnce.Do(func() {
-		config := &kafka.ConfigMap{
-			// Основные настройки подключения
-			"bootstrap.servers": address, // Список Kafka-брокеров для подключения
-			"group.id":          groupID, // Идентификатор группы потребителей
-
-			// Управление оффсетами
-			"auto.offset.reset":        "earliest", // Откуда начинать чтение: earliest (с начала), latest (с конца)
-			"enable.auto.offset.store": false,      // Отключаем автоматическое сохранение оффсетов
-			"enable.auto.commit":       true,       // Отключаем автоматический коммит оффсетов
-			"auto.commit.interval.ms":  5000,       // Интервал коммита
-
-			// Таймауты и надежность
-			"socket.timeout.ms":     60000,  // Таймаут сетевого соединения
-			"session.timeout.ms":    30000,  // Таймаут сессии потребителя
-			"heartbeat.interval.ms": 10000,  // Интервал heartbeat-сообщений
-			"max.poll.interval.ms":  300000, // Макс. время между вызовами poll()
-
-			// Настройки переподключения
-			"socket.keepalive.enable":  true,  // Включаем TCP keepalive
-			"reconnect.backoff.ms":     1000,  // Начальная задержка перед переподключением (1 сек)
-			"reconnect.backoff.max.ms": 10000, // Макс. задержка переподключения (10 сек)
-		}
-
-		consumer, consumerErr = kafka.NewConsumer(config)
-	})
-
-	return consumer, consumerErr
+// NewConsumer creates a consumer. offsetReset is "earliest" for workers
+// that must not miss requests and "latest" for API instances that only care
+// about replies to requests sent after they started
+func NewConsumer(cfg kconf.Config, groupID, offsetReset string) (*kafka.Consumer, error) {
+	config := &kafka.ConfigMap{
+		// Основные настройки подключения
+		"group.id": groupID, // Идентификатор группы потребителей
+
+		// Управление оффсетами
+		"auto.offset.reset":        offsetReset, // Откуда начинать чтение: earliest (с начала), latest (с конца)
+		"enable.auto.offset.store": false,       // Отключаем автоматическое сохранение оффсетов
+		"enable.auto.commit":       false,       // Коммитим оффсеты вручную после обработки сообщения
+
+		// Таймауты и надежность
+		"socket.timeout.ms":     60000,  // Таймаут сетевого соединения
+		"session.timeout.ms":    30000,  // Таймаут сессии потребителя
+		"heartbeat.interval.ms": 10000,  // Интервал heartbeat-сообщений
+		"max.poll.interval.ms":  300000, // Макс. время между вызовами poll()
+
+		// Настройки переподключения
+		"socket.keepalive.enable":  true,  // Включаем TCP keepalive
+		"reconnect.backoff.ms":     1000,  // Начальная задержка перед переподключением (1 сек)
+		"reconnect.backoff.max.ms": 10000, // Макс. задержка переподключения (10 сек)
+
+		// Статистика librdkafka для Prometheus, приходит через Read
+		"statistics.interval.ms": metrics.StatsInterval,
+	}
+
+	// Адрес брокеров, SASL/TLS и переопределения librdkafka
+	if err := cfg.Apply(config); err != nil {
+		return nil, err
+	}
+
+	return kafka.NewConsumer(config)
 }
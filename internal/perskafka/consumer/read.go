@@ -0,0 +1,22 @@
+package perskafka
+
+import (
+	"bookstore-api/internal/metrics"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Read waits for the next message like ReadMessage(-1), but also records
+// the statistics events that ReadMessage drops
+func Read(c *kafka.Consumer) (*kafka.Message, error) {
+	for {
+		switch e := c.Poll(-1).(type) {
+		case *kafka.Message:
+			return e, e.TopicPartition.Error
+		case kafka.Error:
+			return nil, e
+		case *kafka.Stats:
+			metrics.ObserveKafkaStats(e)
+		}
+	}
+}
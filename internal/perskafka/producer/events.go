@@ -0,0 +1,22 @@
+package perskafka
+
+import (
+	"bookstore-api/internal/lib/sl"
+	"bookstore-api/internal/metrics"
+	"log/slog"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// handleEvents drains the events that are not delivery reports, every
+// Produce call gets its own delivery channel. It stops when p is closed
+func handleEvents(p *kafka.Producer) {
+	for ev := range p.Events() {
+		switch e := ev.(type) {
+		case *kafka.Stats:
+			metrics.ObserveKafkaStats(e)
+		case kafka.Error:
+			slog.Error("kafka producer", sl.Error(e))
+		}
+	}
+}
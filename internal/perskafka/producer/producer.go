@@ -1,42 +1,48 @@
 package perskafka
 
 import (
-	"sync"
+	"bookstore-api/internal/metrics"
+	kconf "bookstore-api/internal/perskafka/config"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 )
 
-var (
-	producer     *kafka.Producer
-	producerOnce sync.Once
-	producerErr  error
-)
-
-func GetProducer(address string) (*kafka.Producer, error) {
-	producerOnce.Do(func() {
-		config := &kafka.ConfigMap{
-			// Основные настройки
-			"bootstrap.servers": address, // Список брокеров
-			"acks":              "all",   // Количество подтверждений: all (ждем все реплики)
-			"retries":           3,       // Количество попыток повтора при ошибке
-			"retry.backoff.ms":  1000,    // Задержка между повторами (1 сек)
-
-			// Гарантии доставки
-			"enable.idempotence": true,   // Идемпотентность (предотвращение дублей)
-			"message.timeout.ms": 300000, // Макс. время доставки сообщения (5 мин)
-
-			// Настройки сети
-			"socket.keepalive.enable":            true,  // TCP keepalive
-			"socket.timeout.ms":                  30000, // Таймаут сокета (30 сек)
-			"socket.connection.setup.timeout.ms": 30000, // Таймаут установки соединения (30 сек)
-
-			// Настройки переподключения
-			"reconnect.backoff.ms":     1000,  // Начальная задержка переподключения
-			"reconnect.backoff.max.ms": 10000, // Макс. задержка переподключения
-		}
-
-		producer, producerErr = kafka.NewProducer(config)
-	})
-
-	return producer, producerErr
+// NewProducer creates a producer with delivery guarantees suited for the
+// request/reply and outbox topics
+func NewProducer(cfg kconf.Config) (*kafka.Producer, error) {
+	config := &kafka.ConfigMap{
+		// Основные настройки
+		"acks":             "all", // Количество подтверждений: all (ждем все реплики)
+		"retries":          3,     // Количество попыток повтора при ошибке
+		"retry.backoff.ms": 1000,  // Задержка между повторами (1 сек)
+
+		// Гарантии доставки
+		"enable.idempotence": true,   // Идемпотентность (предотвращение дублей)
+		"message.timeout.ms": 300000, // Макс. время доставки сообщения (5 мин)
+
+		// Настройки сети
+		"socket.keepalive.enable":            true,  // TCP keepalive
+		"socket.timeout.ms":                  30000, // Таймаут сокета (30 сек)
+		"socket.connection.setup.timeout.ms": 30000, // Таймаут установки соединения (30 сек)
+
+		// Настройки переподключения
+		"reconnect.backoff.ms":     1000,  // Начальная задержка переподключения
+		"reconnect.backoff.max.ms": 10000, // Макс. задержка переподключения
+
+		// Статистика librdkafka для Prometheus
+		"statistics.interval.ms": metrics.StatsInterval,
+	}
+
+	// Адрес брокеров, SASL/TLS и переопределения librdkafka
+	if err := cfg.Apply(config); err != nil {
+		return nil, err
+	}
+
+	p, err := kafka.NewProducer(config)
+	if err != nil {
+		return nil, err
+	}
+	go handleEvents(p)
+
+	return p, nil
 }
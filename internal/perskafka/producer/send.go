@@ -0,0 +1,37 @@
+package perskafka
+
+import (
+	"bookstore-api/internal/lib/errs"
+	"bookstore-api/internal/metrics"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Send produces msg and waits for the broker to acknowledge it
+func Send(p *kafka.Producer, msg *kafka.Message) error {
+	start := time.Now()
+	defer func() {
+		metrics.Produce.WithLabelValues(*msg.TopicPartition.Topic).Observe(time.Since(start).Seconds())
+	}()
+
+	deliveryChan := make(chan kafka.Event, 1)
+
+	if err := p.Produce(msg, deliveryChan); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrKafkaProducer, err)
+	}
+
+	e := <-deliveryChan
+	switch event := e.(type) {
+	case *kafka.Message:
+		if event.TopicPartition.Error != nil {
+			return fmt.Errorf("%w: %v", errs.ErrKafkaProducer, event.TopicPartition.Error)
+		}
+		return nil
+	case kafka.Error:
+		return fmt.Errorf("%w: %v", errs.ErrKafkaProducer, event)
+	}
+
+	return nil
+}
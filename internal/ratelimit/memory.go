@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewMemoryStore keeps the buckets in this process. Buckets that filled up
+// again are dropped every cleanup interval
+func NewMemoryStore(cleanup time.Duration) Store {
+	s := &memoryStore{buckets: make(map[string]*bucket)}
+
+	go func() {
+		ticker := time.NewTicker(cleanup)
+		defer ticker.Stop()
+
+		for now := range ticker.C {
+			s.cleanup(now)
+		}
+	}()
+
+	return s
+}
+
+func (s *memoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Capacity())}
+		s.buckets[key] = b
+	}
+
+	// a changed limit, e.g. a new override, starts from a full bucket
+	if b.limit != limit {
+		b.tokens = float64(limit.Capacity())
+		b.last = time.Time{}
+		b.limit = limit
+	}
+
+	var res Result
+	b.tokens, res = take(b.tokens, b.last, now, limit)
+	b.last = now
+
+	return res, nil
+}
+
+func (s *memoryStore) cleanup(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, b := range s.buckets {
+		if now.Sub(b.last) >= b.limit.fillTime(b.tokens) {
+			delete(s.buckets, key)
+		}
+	}
+}
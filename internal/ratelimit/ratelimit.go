@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket: Burst requests at once, refilled at Requests per
+// Period. A zero Burst means Requests
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// Capacity is the size of the bucket
+func (l Limit) Capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// rate returns the tokens added per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// fillTime returns how long a bucket with tokens takes to fill up
+func (l Limit) fillTime(tokens float64) time.Duration {
+	return seconds((float64(l.Capacity()) - tokens) / l.rate())
+}
+
+func (l Limit) Valid() bool {
+	return l.Requests > 0 && l.Period > 0 && l.Burst >= 0
+}
+
+// Result is the state of a bucket after a request took a token from it
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next token, zero when Allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. The memory store limits every instance on its
+// own, a store shared by the instances (e.g. Redis) enforces one limit for
+// all of them
+type Store interface {
+	// Take removes a token from the bucket of key
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// take refills the bucket that had tokens at last and takes a token if one
+// is left. Stores share it, so they compute the same results
+func take(tokens float64, last, now time.Time, limit Limit) (float64, Result) {
+	capacity := float64(limit.Capacity())
+	rate := limit.rate()
+
+	if !last.IsZero() {
+		tokens = math.Min(capacity, tokens+now.Sub(last).Seconds()*rate)
+	}
+
+	res := Result{Limit: limit.Capacity()}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	res.Remaining = int(tokens)
+	res.Reset = limit.fillTime(tokens)
+
+	return tokens, res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Limiter applies the limit of a route group unless the user has an
+// override
+type Limiter struct {
+	store Store
+
+	mu        sync.RWMutex
+	overrides map[uint]Limit
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store, overrides: make(map[uint]Limit)}
+}
+
+// SetOverrides replaces the per user limits
+func (l *Limiter) SetOverrides(overrides map[uint]Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.overrides = overrides
+}
+
+// Take counts a request of key against limit, or against the override of
+// userID when there is one
+func (l *Limiter) Take(ctx context.Context, key string, userID uint, limit Limit) (Result, error) {
+	if userID != 0 {
+		l.mu.RLock()
+		override, ok := l.overrides[userID]
+		l.mu.RUnlock()
+		if ok {
+			limit = override
+		}
+	}
+
+	return l.store.Take(ctx, key, limit)
+}
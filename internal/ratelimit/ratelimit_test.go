@@ -0,0 +1,170 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	// 60 requests a minute is one token per second
+	limit := Limit{Requests: 60, Period: time.Minute, Burst: 3}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		tokens     float64
+		last       time.Time
+		now        time.Time
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+	}{
+		{
+			name:      "first request gets a full bucket",
+			tokens:    3,
+			now:       start,
+			allowed:   true,
+			remaining: 2,
+			reset:     time.Second,
+		},
+		{
+			name:       "empty bucket",
+			tokens:     0,
+			last:       start,
+			now:        start,
+			retryAfter: time.Second,
+			reset:      3 * time.Second,
+		},
+		{
+			name:       "half a token refilled",
+			tokens:     0,
+			last:       start,
+			now:        start.Add(500 * time.Millisecond),
+			retryAfter: 500 * time.Millisecond,
+			reset:      2500 * time.Millisecond,
+		},
+		{
+			name:      "refilled token is taken",
+			tokens:    0,
+			last:      start,
+			now:       start.Add(time.Second),
+			allowed:   true,
+			remaining: 0,
+			reset:     3 * time.Second,
+		},
+		{
+			name:      "refill stops at capacity",
+			tokens:    1,
+			last:      start,
+			now:       start.Add(time.Hour),
+			allowed:   true,
+			remaining: 2,
+			reset:     time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, res := take(tt.tokens, tt.last, tt.now, limit)
+
+			want := Result{
+				Allowed:    tt.allowed,
+				Limit:      3,
+				Remaining:  tt.remaining,
+				Reset:      tt.reset,
+				RetryAfter: tt.retryAfter,
+			}
+			if res != want {
+				t.Fatalf("take = %+v, want %+v", res, want)
+			}
+		})
+	}
+}
+
+func TestLimitCapacity(t *testing.T) {
+	tests := []struct {
+		limit Limit
+		want  int
+		valid bool
+	}{
+		{Limit{Requests: 10, Period: time.Minute, Burst: 5}, 5, true},
+		{Limit{Requests: 10, Period: time.Minute}, 10, true},
+		{Limit{Requests: 0, Period: time.Minute}, 0, false},
+		{Limit{Requests: 10}, 10, false},
+		{Limit{Requests: 10, Period: time.Minute, Burst: -1}, 10, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.limit.Capacity(); got != tt.want {
+			t.Errorf("%+v Capacity = %d, want %d", tt.limit, got, tt.want)
+		}
+		if got := tt.limit.Valid(); got != tt.valid {
+			t.Errorf("%+v Valid = %v, want %v", tt.limit, got, tt.valid)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	group := Limit{Requests: 2, Period: time.Hour}
+	override := Limit{Requests: 5, Period: time.Hour}
+
+	l := NewLimiter(NewMemoryStore(time.Hour))
+	l.SetOverrides(map[uint]Limit{7: override})
+
+	tests := []struct {
+		name    string
+		key     string
+		userID  uint
+		allowed int
+	}{
+		{"group limit", "api:user:1", 1, 2},
+		{"override", "api:user:7", 7, 5},
+		{"anonymous client", "api:ip:10.0.0.1", 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed := 0
+			for range 10 {
+				res, err := l.Take(ctx, tt.key, tt.userID, group)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Allowed {
+					allowed++
+				}
+			}
+			if allowed != tt.allowed {
+				t.Fatalf("%d requests allowed, want %d", allowed, tt.allowed)
+			}
+		})
+	}
+
+	// a new override starts from a full bucket
+	l.SetOverrides(map[uint]Limit{1: override})
+	if res, _ := l.Take(ctx, "api:user:1", 1, group); !res.Allowed || res.Remaining != 4 {
+		t.Fatalf("after override = %+v, want a full bucket", res)
+	}
+}
+
+func TestMemoryStoreCleanup(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 60, Period: time.Minute, Burst: 2}
+
+	s := &memoryStore{buckets: make(map[string]*bucket)}
+	s.Take(ctx, "a", limit)
+	s.Take(ctx, "b", limit)
+	s.Take(ctx, "b", limit)
+
+	// a is full again after a second, b after two
+	s.cleanup(time.Now().Add(1500 * time.Millisecond))
+	if _, ok := s.buckets["a"]; ok {
+		t.Error("full bucket was kept")
+	}
+	if _, ok := s.buckets["b"]; !ok {
+		t.Error("bucket still filling was dropped")
+	}
+}
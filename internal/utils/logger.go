@@ -1,21 +1,50 @@
 package utils
 
 import (
-	"fmt"
-	"time"
+	"bookstore-api/internal/lib/reqctx"
+	"context"
+	"log/slog"
+	"os"
 
-	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func Log(param gin.LogFormatterParams) string {
-	return fmt.Sprintf("{%s - [%s] %s %s %d %s |%s| %s}",
-		param.ClientIP,
-		param.TimeStamp.Format(time.RFC1123),
-		param.Path,
-		param.Method,
-		param.StatusCode,
-		param.Latency,
-		param.Request.UserAgent(),
-		param.ErrorMessage,
-	)
+// InitLogger makes a JSON slog logger the default one. debug enables debug
+// records
+func InitLogger(debug bool) {
+	logLevel := slog.LevelInfo
+	if debug {
+		logLevel = slog.LevelDebug
+	}
+	logger := slog.New(contextHandler{slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: logLevel,
+	})})
+	slog.SetDefault(logger)
+}
+
+// contextHandler adds the request ID and the trace of the context to records
+// logged with the *Context functions
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := reqctx.RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
 }